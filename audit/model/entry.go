@@ -0,0 +1,161 @@
+package model
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Entry is a change recorded in the audit log. Entries are never modified once recorded.
+type Entry struct {
+	id     string
+	action string // Action is what was changed, see the audit.Action constants.
+	actor  string // Actor is who made the change, empty if unknown.
+
+	playerID string // PlayerID is the ID of the player the change is about, if any.
+	groupID  string // GroupID is the ID of the group the change is about, if any.
+
+	at time.Time
+
+	before map[string]interface{} // Before is the marshaled target before the change, nil if it did not exist.
+	after  map[string]interface{} // After is the marshaled target after the change, nil if it no longer exists.
+}
+
+func NewEntry(id, action, actor, playerID, groupID string, at time.Time, before, after map[string]interface{}) *Entry {
+	return &Entry{
+		id:       id,
+		action:   action,
+		actor:    actor,
+		playerID: playerID,
+		groupID:  groupID,
+		at:       at,
+		before:   before,
+		after:    after,
+	}
+}
+
+// ID returns the ID of the entry.
+func (e *Entry) ID() string {
+	return e.id
+}
+
+// Action returns what was changed.
+func (e *Entry) Action() string {
+	return e.action
+}
+
+// Actor returns who made the change, empty if unknown.
+func (e *Entry) Actor() string {
+	return e.actor
+}
+
+// PlayerID returns the ID of the player the change is about, if any.
+func (e *Entry) PlayerID() string {
+	return e.playerID
+}
+
+// GroupID returns the ID of the group the change is about, if any.
+func (e *Entry) GroupID() string {
+	return e.groupID
+}
+
+// At returns when the change was made.
+func (e *Entry) At() time.Time {
+	return e.at
+}
+
+// Before returns the marshaled target before the change, nil if it did not exist.
+func (e *Entry) Before() map[string]interface{} {
+	return e.before
+}
+
+// After returns the marshaled target after the change, nil if it no longer exists.
+func (e *Entry) After() map[string]interface{} {
+	return e.after
+}
+
+// Marshal returns the entry as a map.
+func (e *Entry) Marshal() map[string]interface{} {
+	return map[string]interface{}{
+		"_id":    e.id,
+		"action": e.action,
+		"actor":  e.actor,
+
+		"player_id": e.playerID,
+		"group_id":  e.groupID,
+
+		"at": e.at.Unix(),
+
+		"before": e.before,
+		"after":  e.after,
+	}
+}
+
+// Unmarshal unmarshals the entry from the given map.
+func (e *Entry) Unmarshal(body map[string]interface{}) error {
+	id, ok := body["_id"].(string)
+	if !ok {
+		return errors.New("_id is not a string")
+	}
+	e.id = id
+
+	action, ok := body["action"].(string)
+	if !ok {
+		return errors.New("action is not a string")
+	}
+	e.action = action
+
+	e.actor, _ = body["actor"].(string)
+	e.playerID, _ = body["player_id"].(string)
+	e.groupID, _ = body["group_id"].(string)
+
+	at, ok := toInt64(body["at"])
+	if !ok {
+		return errors.New("at is not an integer")
+	}
+	e.at = time.Unix(at, 0)
+
+	before, ok := toMap(body["before"])
+	if !ok {
+		return errors.New("before is not an object")
+	}
+	e.before = before
+
+	after, ok := toMap(body["after"])
+	if !ok {
+		return errors.New("after is not an object")
+	}
+	e.after = after
+
+	return nil
+}
+
+// toInt64 converts the numeric types produced by the BSON and JSON decoders to int64.
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case int32:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// toMap converts the object types produced by the BSON and JSON decoders to a map.
+func toMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case nil:
+		return nil, true
+	case map[string]interface{}:
+		return m, true
+	case primitive.M:
+		return m, true
+	default:
+		return nil, false
+	}
+}
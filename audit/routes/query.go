@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/audit"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/gofiber/fiber/v3"
+	"strconv"
+	"time"
+)
+
+// Query handles the paginated query of the audit log, newest entries first.
+// Every filter is optional: actor, player_id, group_id and the from and to unix times.
+func Query(ctx fiber.Ctx) error {
+	limit := audit.DefaultQueryLimit
+	if raw := ctx.Query("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid limit provided",
+			})
+		}
+
+		limit = v
+	}
+
+	filter := audit.Filter{
+		Actor:    ctx.Query("actor"),
+		PlayerID: ctx.Query("player_id"),
+		GroupID:  ctx.Query("group_id"),
+	}
+	if from, ok := parseUnix(ctx.Query("from")); !ok {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid from provided",
+		})
+	} else if to, ok := parseUnix(ctx.Query("to")); !ok {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid to provided",
+		})
+	} else {
+		filter.From, filter.To = from, to
+	}
+
+	entries, next, err := audit.Service().Query(filter, ctx.Query("cursor"), limit)
+	if errors.Is(err, audit.ErrInvalidQuery) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": helper.ServiceId + ": " + err.Error(),
+		})
+	}
+
+	values := make([]map[string]interface{}, 0, len(entries))
+	for _, e := range entries {
+		values = append(values, e.Marshal())
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"entries": values,
+		"next":    next,
+	})
+}
+
+// parseUnix parses the unix time of a query, the zero time if it is empty.
+func parseUnix(raw string) (time.Time, bool) {
+	if raw == "" {
+		return time.Time{}, true
+	}
+
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || v < 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(v, 0), true
+}
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Mides-Projects/Kyro/audit/model"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/google/uuid"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ActionGrantIssue    = "grant.issue"
+	ActionGrantRevoke   = "grant.revoke"
+	ActionGrantExtend   = "grant.extend"
+	ActionGrantReassign = "grant.reassign"
+
+	ActionGroupCreate           = "group.create"
+	ActionGroupUpdate           = "group.update"
+	ActionGroupDelete           = "group.delete"
+	ActionGroupAddPermission    = "group.add_permission"
+	ActionGroupRemovePermission = "group.remove_permission"
+)
+
+const (
+	// DefaultQueryLimit is the number of entries of a page when no limit is requested.
+	DefaultQueryLimit = 50
+	// MaxQueryLimit is the maximum number of entries of a page.
+	MaxQueryLimit = 200
+)
+
+type ServiceImpl struct {
+	// store persists the audit log, see EntryStore.
+	store EntryStore
+}
+
+// Record appends a change to the audit log. The player and group IDs are the
+// targets of the change used to query it later, and before and after are the
+// marshaled target around the change, nil when it did not exist or no longer exists.
+// The change already happened, so a failure is logged instead of returned.
+func (s *ServiceImpl) Record(action, actor, playerID, groupID string, before, after map[string]interface{}) {
+	if s.store == nil {
+		return // Not hooked, e.g. a service embedded by a tool.
+	}
+
+	e := model.NewEntry(uuid.New().String(), action, actor, playerID, groupID, time.Now(), before, after)
+	if err := s.store.Insert(e); err != nil {
+		helper.Log.Error(helper.ServiceId+": failed to record audit entry", "error", err, "action", action, "actor", actor)
+	}
+}
+
+// Query returns a page of the entries matching the filter, newest first, and the
+// cursor of the next page, empty if it is the last one. The cursor is the one
+// returned for the previous page, or empty for the first page.
+func (s *ServiceImpl) Query(filter Filter, cursor string, limit int) ([]*model.Entry, string, error) {
+	if s.store == nil {
+		return nil, "", errors.New(helper.ServiceId + ": no audit store")
+	} else if limit <= 0 || limit > MaxQueryLimit {
+		return nil, "", fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxQueryLimit)
+	} else if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, "", fmt.Errorf("%w: from must be before to", ErrInvalidQuery)
+	}
+
+	var after *Cursor
+	if cursor != "" {
+		rawAt, id, ok := strings.Cut(cursor, ":")
+		at, err := strconv.ParseInt(rawAt, 10, 64)
+		if !ok || err != nil || id == "" {
+			return nil, "", fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+		}
+
+		after = &Cursor{At: time.Unix(at, 0), ID: id}
+	}
+
+	// One more entry than requested tells if there is a next page.
+	entries, err := s.store.Find(filter, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	} else if len(entries) <= limit {
+		return entries, "", nil
+	}
+
+	entries = entries[:limit]
+	last := entries[limit-1]
+
+	return entries, strconv.FormatInt(last.At().Unix(), 10) + ":" + last.ID(), nil
+}
+
+// Hooked returns if the service has a store to record the changes in.
+func (s *ServiceImpl) Hooked() bool {
+	return s.store != nil
+}
+
+// Hook initializes the audit service.
+func (s *ServiceImpl) Hook() error {
+	if s.store != nil {
+		return errors.New(helper.ServiceId + ": audit store already set")
+	}
+
+	store, err := newStore()
+	if err != nil {
+		return errors.Join(errors.New(helper.ServiceId+": failed to open the audit store"), err)
+	}
+	s.store = store
+
+	return nil
+}
+
+// NewService returns a service backed by the given store.
+// Unlike Hook it does not connect to MongoDB, see MemoryStore.
+func NewService(store EntryStore) *ServiceImpl {
+	return &ServiceImpl{
+		store: store,
+	}
+}
+
+// Service returns the service.
+func Service() *ServiceImpl {
+	return service
+}
+
+var service = &ServiceImpl{}
+
+var (
+	// ErrInvalidQuery is returned when the audit log is queried with invalid input.
+	ErrInvalidQuery = errors.New("invalid query")
+)
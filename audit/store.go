@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/audit/model"
+	"github.com/Mides-Projects/Kyro/storage"
+	"github.com/Mides-Projects/Operator/helper"
+	"time"
+)
+
+// Filter selects the entries of a query. Empty fields match every entry.
+type Filter struct {
+	Actor    string    // Actor is who made the changes.
+	PlayerID string    // PlayerID is the ID of the player the changes are about.
+	GroupID  string    // GroupID is the ID of the group the changes are about.
+	From     time.Time // From is the time the changes were made at or after.
+	To       time.Time // To is the time the changes were made before.
+}
+
+// Cursor is the position of an entry in the audit log, which is ordered
+// from the newest entry to the oldest one.
+type Cursor struct {
+	At time.Time
+	ID string
+}
+
+// EntryStore persists the audit log. Entries are only ever appended.
+type EntryStore interface {
+	// Insert appends the entry.
+	Insert(e *model.Entry) error
+	// Find returns at most limit entries matching the filter, newest first,
+	// starting after the cursor or from the newest entry if it is nil.
+	Find(filter Filter, after *Cursor, limit int) ([]*model.Entry, error)
+}
+
+// newStore returns the EntryStore of the storage driver selected by the configuration.
+func newStore() (EntryStore, error) {
+	switch driver := storage.Driver(); driver {
+	case storage.DriverMongo:
+		ms := NewMongoStore(helper.MongoClient.Database(helper.MongoDBName).Collection("audit"))
+		if err := ms.EnsureIndexes(); err != nil {
+			return nil, err
+		}
+
+		return ms, nil
+	case storage.DriverSQLite:
+		db, err := storage.SQLite()
+		if err != nil {
+			return nil, err
+		}
+
+		return NewSQLiteStore(db), nil
+	default:
+		return nil, errors.New("unknown storage driver '" + driver + "'")
+	}
+}
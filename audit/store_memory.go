@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"github.com/Mides-Projects/Kyro/audit/model"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MemoryStore is an EntryStore that keeps the audit log in memory.
+// The entries are stored marshaled so callers never share them with the store.
+type MemoryStore struct {
+	values []map[string]interface{}
+	mu     sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Insert appends the entry.
+func (ms *MemoryStore) Insert(e *model.Entry) error {
+	ms.mu.Lock()
+	ms.values = append(ms.values, e.Marshal())
+	ms.mu.Unlock()
+
+	return nil
+}
+
+// Find returns at most limit entries matching the filter, newest first,
+// starting after the cursor or from the newest entry if it is nil.
+func (ms *MemoryStore) Find(filter Filter, after *Cursor, limit int) ([]*model.Entry, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var entries []*model.Entry
+	for _, body := range ms.values {
+		e := &model.Entry{}
+		if err := e.Unmarshal(body); err != nil {
+			return nil, err
+		}
+
+		if filter.Actor != "" && e.Actor() != filter.Actor {
+			continue
+		} else if filter.PlayerID != "" && e.PlayerID() != filter.PlayerID {
+			continue
+		} else if filter.GroupID != "" && e.GroupID() != filter.GroupID {
+			continue
+		} else if !filter.From.IsZero() && e.At().Unix() < filter.From.Unix() {
+			continue
+		} else if !filter.To.IsZero() && e.At().Unix() >= filter.To.Unix() {
+			continue
+		} else if after != nil && compareEntry(e, after.At.Unix(), after.ID) >= 0 {
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	slices.SortFunc(entries, func(a, b *model.Entry) int {
+		return compareEntry(b, a.At().Unix(), a.ID())
+	})
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
+
+// compareEntry compares the position of the entry with the given one, oldest first.
+func compareEntry(e *model.Entry, at int64, id string) int {
+	if e.At().Unix() != at {
+		if e.At().Unix() < at {
+			return -1
+		}
+
+		return 1
+	}
+
+	return strings.Compare(e.ID(), id)
+}
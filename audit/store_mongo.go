@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"context"
+	"github.com/Mides-Projects/Kyro/audit/model"
+	"github.com/Mides-Projects/Kyro/storage"
+	"github.com/Mides-Projects/Operator/helper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore is an EntryStore backed by a MongoDB collection.
+type MongoStore struct {
+	col *mongo.Collection
+	ctx context.Context
+}
+
+func NewMongoStore(col *mongo.Collection) *MongoStore {
+	return &MongoStore{
+		col: col,
+		// caching the context helps a lot with performance and memory usage
+		ctx: context.Background(),
+	}
+}
+
+// indexes are the indexes the audit collection relies on, one per filter
+// followed by the time so the entries are read in order.
+var indexes = []storage.Index{
+	{Name: "at", Keys: []string{"at", "_id"}},
+	{Name: "actor_at", Keys: []string{"actor", "at"}},
+	{Name: "player_id_at", Keys: []string{"player_id", "at"}},
+	{Name: "group_id_at", Keys: []string{"group_id", "at"}},
+}
+
+// EnsureIndexes creates the indexes the store relies on if they are missing.
+func (ms *MongoStore) EnsureIndexes() error {
+	return storage.EnsureIndexes(ms.ctx, ms.col, indexes)
+}
+
+// Insert appends the entry.
+func (ms *MongoStore) Insert(e *model.Entry) error {
+	_, err := ms.col.InsertOne(ms.ctx, e.Marshal())
+
+	return err
+}
+
+// Find returns at most limit entries matching the filter, newest first,
+// starting after the cursor or from the newest entry if it is nil.
+func (ms *MongoStore) Find(filter Filter, after *Cursor, limit int) ([]*model.Entry, error) {
+	conditions := bson.A{}
+	if filter.Actor != "" {
+		conditions = append(conditions, bson.M{"actor": filter.Actor})
+	}
+	if filter.PlayerID != "" {
+		conditions = append(conditions, bson.M{"player_id": filter.PlayerID})
+	}
+	if filter.GroupID != "" {
+		conditions = append(conditions, bson.M{"group_id": filter.GroupID})
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, bson.M{"at": bson.M{"$gte": filter.From.Unix()}})
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, bson.M{"at": bson.M{"$lt": filter.To.Unix()}})
+	}
+	if after != nil {
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"at": bson.M{"$lt": after.At.Unix()}},
+			bson.M{"at": after.At.Unix(), "_id": bson.M{"$lt": after.ID}},
+		}})
+	}
+
+	query := bson.M{}
+	if len(conditions) > 0 {
+		query["$and"] = conditions
+	}
+
+	cur, err := ms.col.Find(
+		ms.ctx,
+		query,
+		options.Find().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ms.ctx)
+
+	var entries []*model.Entry
+	for cur.Next(ms.ctx) {
+		var body map[string]interface{}
+		e := &model.Entry{}
+
+		if err = cur.Decode(&body); err != nil {
+			helper.Log.Error(helper.ServiceId+": failed to decode audit entry", "error", err)
+		} else if err = e.Unmarshal(body); err != nil {
+			helper.Log.Error(helper.ServiceId+": failed to unmarshal audit entry", "error", err, "body", body)
+		} else {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, cur.Err()
+}
@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"database/sql"
+	"github.com/Mides-Projects/Kyro/audit/model"
+	"github.com/bytedance/sonic"
+	"strings"
+)
+
+// SQLiteStore is an EntryStore backed by the audit table of an SQLite database,
+// see storage.SQLite for the schema. The snapshots are kept as JSON.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{
+		db: db,
+	}
+}
+
+// Insert appends the entry.
+func (ss *SQLiteStore) Insert(e *model.Entry) error {
+	before, err := marshalSnapshot(e.Before())
+	if err != nil {
+		return err
+	}
+
+	after, err := marshalSnapshot(e.After())
+	if err != nil {
+		return err
+	}
+
+	_, err = ss.db.Exec(
+		`INSERT INTO audit (id, action, actor, player_id, group_id, at, before_body, after_body) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ID(),
+		e.Action(),
+		e.Actor(),
+		e.PlayerID(),
+		e.GroupID(),
+		e.At().Unix(),
+		before,
+		after,
+	)
+
+	return err
+}
+
+// Find returns at most limit entries matching the filter, newest first,
+// starting after the cursor or from the newest entry if it is nil.
+func (ss *SQLiteStore) Find(filter Filter, after *Cursor, limit int) ([]*model.Entry, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if filter.Actor != "" {
+		conditions, args = append(conditions, `actor = ?`), append(args, filter.Actor)
+	}
+	if filter.PlayerID != "" {
+		conditions, args = append(conditions, `player_id = ?`), append(args, filter.PlayerID)
+	}
+	if filter.GroupID != "" {
+		conditions, args = append(conditions, `group_id = ?`), append(args, filter.GroupID)
+	}
+	if !filter.From.IsZero() {
+		conditions, args = append(conditions, `at >= ?`), append(args, filter.From.Unix())
+	}
+	if !filter.To.IsZero() {
+		conditions, args = append(conditions, `at < ?`), append(args, filter.To.Unix())
+	}
+	if after != nil {
+		conditions, args = append(conditions, `(at < ? OR (at = ? AND id < ?))`), append(args, after.At.Unix(), after.At.Unix(), after.ID)
+	}
+
+	query := `SELECT id, action, actor, player_id, group_id, at, before_body, after_body FROM audit`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+
+	rows, err := ss.db.Query(query+` ORDER BY at DESC, id DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*model.Entry
+	for rows.Next() {
+		var (
+			id, action, actor, playerID, groupID string
+			at                                   int64
+			before, after                        sql.NullString
+		)
+		if err = rows.Scan(&id, &action, &actor, &playerID, &groupID, &at, &before, &after); err != nil {
+			return nil, err
+		}
+
+		body := map[string]interface{}{
+			"_id":       id,
+			"action":    action,
+			"actor":     actor,
+			"player_id": playerID,
+			"group_id":  groupID,
+			"at":        at,
+		}
+		if body["before"], err = unmarshalSnapshot(before); err != nil {
+			return nil, err
+		} else if body["after"], err = unmarshalSnapshot(after); err != nil {
+			return nil, err
+		}
+
+		e := &model.Entry{}
+		if err = e.Unmarshal(body); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// marshalSnapshot returns the snapshot as JSON, or NULL if there is none.
+func marshalSnapshot(snapshot map[string]interface{}) (sql.NullString, error) {
+	if snapshot == nil {
+		return sql.NullString{}, nil
+	}
+
+	raw, err := sonic.MarshalString(snapshot)
+
+	return sql.NullString{String: raw, Valid: err == nil}, err
+}
+
+// unmarshalSnapshot returns the snapshot stored as JSON, or nil if there is none.
+func unmarshalSnapshot(raw sql.NullString) (map[string]interface{}, error) {
+	if !raw.Valid {
+		return nil, nil
+	}
+
+	var snapshot map[string]interface{}
+	if err := sonic.UnmarshalString(raw.String, &snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
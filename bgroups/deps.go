@@ -0,0 +1,26 @@
+package bgroups
+
+import (
+	"github.com/Mides-Projects/Kyro/audit"
+	"github.com/Mides-Projects/Operator/helper"
+)
+
+// Dependencies are the services the group service relies on besides its store.
+// NewService uses the global service of every field left empty.
+type Dependencies struct {
+	Audit *audit.ServiceImpl
+	// Publish announces a change to the other instances, helper.PublishNats by default.
+	Publish func(subject string, body map[string]interface{})
+}
+
+// withDefaults returns the dependencies with the global services in place of the empty fields.
+func (d Dependencies) withDefaults() Dependencies {
+	if d.Audit == nil {
+		d.Audit = audit.Service()
+	}
+	if d.Publish == nil {
+		d.Publish = helper.PublishNats
+	}
+
+	return d
+}
@@ -0,0 +1,117 @@
+package bgroups
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"github.com/Mides-Projects/Kyro/bgroups/model"
+	"github.com/bytedance/sonic"
+	"slices"
+	"strings"
+)
+
+const (
+	// SortName sorts the groups by name, ignoring case.
+	SortName = "name"
+	// SortWeight sorts the groups by weight, heaviest first, and then by name.
+	SortWeight = "weight"
+)
+
+const (
+	// DefaultListLimit is the number of groups of a page when no limit is requested.
+	DefaultListLimit = 50
+	// MaxListLimit is the maximum number of groups of a page.
+	MaxListLimit = 200
+)
+
+// ListQuery selects the groups of a page, see ServiceImpl.List.
+type ListQuery struct {
+	Sort   string // Sort is SortName or SortWeight, SortName if empty.
+	Prefix string // Prefix is the start of the names of the groups, ignoring case.
+	Cursor string // Cursor is the cursor returned for the previous page, empty for the first page.
+	Limit  int    // Limit is the maximum number of groups of the page.
+}
+
+// listCursor is the position of the last group of a page. It keeps the sort keys
+// instead of the index so a page never skips or repeats a group when groups are
+// created or deleted between two pages.
+type listCursor struct {
+	Weight int    `json:"w"`
+	Name   string `json:"n"`
+	ID     string `json:"i"`
+}
+
+// List returns a page of the groups matching the query, in a stable order, and the cursor
+// of the next page, empty if it is the last one.
+func (s *ServiceImpl) List(q ListQuery) ([]*model.Group, string, error) {
+	if q.Sort == "" {
+		q.Sort = SortName
+	}
+
+	if q.Sort != SortName && q.Sort != SortWeight {
+		return nil, "", fmt.Errorf("%w: unknown sort '%s'", ErrInvalidQuery, q.Sort)
+	} else if q.Limit <= 0 || q.Limit > MaxListLimit {
+		return nil, "", fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxListLimit)
+	}
+
+	var after *listCursor
+	if q.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+		}
+
+		after = &listCursor{}
+		if err = sonic.Unmarshal(raw, after); err != nil || after.ID == "" {
+			return nil, "", fmt.Errorf("%w: invalid cursor", ErrInvalidQuery)
+		}
+	}
+
+	prefix := strings.ToLower(q.Prefix)
+
+	var cursors []listCursor
+	groups := map[string]*model.Group{}
+	for _, g := range s.Values() {
+		c := listCursor{Weight: g.Weight(), Name: strings.ToLower(g.Name()), ID: g.ID()}
+		if !strings.HasPrefix(c.Name, prefix) {
+			continue
+		} else if after != nil && compareCursors(q.Sort, c, *after) <= 0 {
+			continue
+		}
+
+		cursors = append(cursors, c)
+		groups[c.ID] = g
+	}
+
+	slices.SortFunc(cursors, func(a, b listCursor) int {
+		return compareCursors(q.Sort, a, b)
+	})
+
+	next := ""
+	if len(cursors) > q.Limit {
+		cursors = cursors[:q.Limit]
+
+		raw, err := sonic.Marshal(cursors[q.Limit-1])
+		if err != nil {
+			return nil, "", err
+		}
+		next = base64.RawURLEncoding.EncodeToString(raw)
+	}
+
+	page := make([]*model.Group, 0, len(cursors))
+	for _, c := range cursors {
+		page = append(page, groups[c.ID])
+	}
+
+	return page, next, nil
+}
+
+// compareCursors compares the positions of two groups in the given sort.
+// The ID breaks the ties so the order is total.
+func compareCursors(sort string, a, b listCursor) int {
+	if sort == SortWeight && a.Weight != b.Weight {
+		return cmp.Compare(b.Weight, a.Weight)
+	}
+
+	return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.ID, b.ID))
+}
@@ -21,8 +21,13 @@ type Group struct {
     chatPrefix string // ChatPrefix is the prefix for chat messages.
     chatSuffix string // ChatSuffix is the suffix for chat messages.
 
+    weight int // Weight is the priority of the group, the highest weight is the primary group.
+
     permissionsMu sync.RWMutex // PermissionsMu is the mutex for the permissions.
     permissions   []string     // Permissions is the list of permissions the group has.
+
+    parentsMu sync.RWMutex // ParentsMu is the mutex for the parents.
+    parents   []string     // Parents is the list of group IDs the group inherits from.
 }
 
 func NewGroup(id, name string) *Group {
@@ -42,6 +47,11 @@ func (g *Group) Name() string {
     return g.name
 }
 
+// SetName sets the name of the group.
+func (g *Group) SetName(name string) {
+    g.name = name
+}
+
 // DisplayName returns the display name of the group.
 func (g *Group) DisplayName() string {
     return g.displayName
@@ -102,6 +112,16 @@ func (g *Group) SetChatSuffix(chatSuffix string) {
     g.chatSuffix = chatSuffix
 }
 
+// Weight returns the weight of the group.
+func (g *Group) Weight() int {
+    return g.weight
+}
+
+// SetWeight sets the weight of the group.
+func (g *Group) SetWeight(weight int) {
+    g.weight = weight
+}
+
 // Permissions returns the permissions of the group.
 func (g *Group) Permissions() []string {
     g.permissionsMu.RLock()
@@ -111,21 +131,69 @@ func (g *Group) Permissions() []string {
 }
 
 // AddPermission adds the permission to the group.
-func (g *Group) AddPermission(permission string) {
+// It returns false if the group already has the permission.
+func (g *Group) AddPermission(permission string) bool {
     g.permissionsMu.Lock()
+    defer g.permissionsMu.Unlock()
+
+    if slices.Contains(g.permissions, permission) {
+        return false
+    }
+
     g.permissions = append(g.permissions, permission)
-    g.permissionsMu.Unlock()
+
+    return true
 }
 
 // RemovePermission removes the permission from the group.
-func (g *Group) RemovePermission(permission string) {
+// It returns false if the group does not have the permission.
+func (g *Group) RemovePermission(permission string) bool {
     g.permissionsMu.Lock()
+    defer g.permissionsMu.Unlock()
 
-    if indx := slices.Index(g.permissions, permission); indx != -1 {
-        g.permissions = append(g.permissions[:indx], g.permissions[indx+1:]...)
+    indx := slices.Index(g.permissions, permission)
+    if indx == -1 {
+        return false
     }
 
-    g.permissionsMu.Unlock()
+    g.permissions = slices.Delete(slices.Clone(g.permissions), indx, indx+1)
+
+    return true
+}
+
+// Parents returns the IDs of the groups the group inherits from.
+func (g *Group) Parents() []string {
+    g.parentsMu.RLock()
+    defer g.parentsMu.RUnlock()
+
+    return g.parents
+}
+
+// SetParents sets the IDs of the groups the group inherits from.
+func (g *Group) SetParents(parents []string) {
+    g.parentsMu.Lock()
+    g.parents = parents
+    g.parentsMu.Unlock()
+}
+
+// Clone returns a copy of the group sharing nothing with it,
+// so the copy can be changed while the group is still being read.
+func (g *Group) Clone() *Group {
+    c := &Group{
+        id:          g.id,
+        name:        g.name,
+        displayName: g.displayName,
+        charColor:   g.charColor,
+        prefix:      g.prefix,
+        suffix:      g.suffix,
+        chatPrefix:  g.chatPrefix,
+        chatSuffix:  g.chatSuffix,
+        weight:      g.weight,
+    }
+    c.permissions = slices.Clone(g.Permissions())
+    c.parents = slices.Clone(g.Parents())
+
+    return c
 }
 
 // Marshal marshals the group into a map.
@@ -158,10 +226,18 @@ func (g *Group) Marshal() map[string]interface{} {
         body["chat_suffix"] = g.chatSuffix
     }
 
+    if g.weight != 0 {
+        body["weight"] = g.weight
+    }
+
     if len(g.permissions) > 0 {
         body["permissions"] = g.Permissions()
     }
 
+    if parents := g.Parents(); len(parents) > 0 {
+        body["parents"] = parents
+    }
+
     return body
 }
 
@@ -205,11 +281,54 @@ func (g *Group) Unmarshal(body map[string]interface{}) error {
         g.chatSuffix = chatSuffix
     }
 
-    if permissions, ok := body["permissions"].([]string); ok {
-        g.permissionsMu.Lock()
-        g.permissions = permissions
-        g.permissionsMu.Unlock()
+    switch v := body["weight"].(type) {
+    case int32:
+        g.weight = int(v)
+    case int64:
+        g.weight = int(v)
+    case int:
+        g.weight = v
+    case float64:
+        g.weight = int(v)
+    }
+
+    var permissions []string
+    switch v := body["permissions"].(type) {
+    case []string:
+        permissions = v
+    case primitive.A:
+        permissions = toStrings(v)
+    case []interface{}:
+        permissions = toStrings(v)
     }
 
+    g.permissionsMu.Lock()
+    g.permissions = permissions
+    g.permissionsMu.Unlock()
+
+    var parents []string
+    switch v := body["parents"].(type) {
+    case []string:
+        parents = v
+    case primitive.A:
+        parents = toStrings(v)
+    case []interface{}:
+        parents = toStrings(v)
+    }
+
+    g.SetParents(parents)
+
     return nil
 }
+
+// toStrings returns the string values of the given array, skipping the rest.
+func toStrings(values []interface{}) []string {
+    result := make([]string, 0, len(values))
+    for _, v := range values {
+        if s, ok := v.(string); ok {
+            result = append(result, s)
+        }
+    }
+
+    return result
+}
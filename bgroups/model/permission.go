@@ -0,0 +1,55 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// ContextSeparator separates the node of a stored permission from its contexts.
+const ContextSeparator = "@"
+
+// FormatPermission returns the stored form of the node limited to the given contexts,
+// e.g. "bedwars.kit.vip@gamemode=bedwars,server=lobby". The node and the contexts are
+// lowercased and the contexts sorted by key so the same permission is always stored the same way.
+func FormatPermission(node string, contexts map[string]string) string {
+	node = strings.ToLower(node)
+	if len(contexts) == 0 {
+		return node
+	}
+
+	pairs := make([]string, 0, len(contexts))
+	for k, v := range contexts {
+		pairs = append(pairs, strings.ToLower(k)+"="+strings.ToLower(v))
+	}
+	sort.Strings(pairs)
+
+	return node + ContextSeparator + strings.Join(pairs, ",")
+}
+
+// ParsePermission splits the stored permission into its node and contexts.
+// The contexts are nil if the permission applies everywhere.
+func ParsePermission(permission string) (string, map[string]string) {
+	node, raw, ok := strings.Cut(permission, ContextSeparator)
+	if !ok || raw == "" {
+		return node, nil
+	}
+
+	contexts := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, _ := strings.Cut(pair, "=")
+		contexts[strings.ToLower(k)] = strings.ToLower(v)
+	}
+
+	return node, contexts
+}
+
+// ContextsApply returns if every context of a permission is satisfied by the caller contexts.
+func ContextsApply(contexts, caller map[string]string) bool {
+	for k, v := range contexts {
+		if !strings.EqualFold(caller[k], v) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,101 @@
+package bgroups
+
+import (
+	"fmt"
+	"github.com/Mides-Projects/Kyro/bgroups/model"
+	"strings"
+	"unicode"
+)
+
+// Resolution is the outcome of resolving a permission node in a group hierarchy.
+type Resolution struct {
+	Allowed bool   // Allowed is if the node is allowed.
+	GroupID string // GroupID is the ID of the group defining the entry.
+	Entry   string // Entry is the permission entry that decided the node.
+}
+
+// MatchPermission resolves the node against the given permission entries for the caller contexts.
+// An entry starting with "-" denies the node instead of allowing it, and an entry
+// ending with ".*" matches every node below its prefix ("*" alone matches every node).
+// Entries limited to contexts (see model.FormatPermission) only apply when the caller
+// has the same contexts.
+// The most specific matching entry wins: an exact entry beats any wildcard and a longer
+// wildcard beats a shorter one. At equal specificity an entry with more contexts beats
+// a more general one, and then a negated entry beats an allowing one.
+// It returns if the node is allowed, the entry that decided it and false as the last value
+// if no entry matches the node.
+func MatchPermission(permissions []string, node string, contexts map[string]string) (bool, string, bool) {
+	node = strings.ToLower(node)
+
+	best, bestSpecificity, bestContexts, allowed := "", -1, -1, false
+	for _, permission := range permissions {
+		entry, entryContexts := model.ParsePermission(permission)
+		pattern, negated := strings.CutPrefix(strings.ToLower(entry), "-")
+
+		specificity := permissionSpecificity(pattern, node)
+		if specificity < 0 || !model.ContextsApply(entryContexts, contexts) {
+			continue
+		} else if specificity < bestSpecificity {
+			continue
+		} else if specificity == bestSpecificity && len(entryContexts) < bestContexts {
+			continue
+		} else if specificity == bestSpecificity && len(entryContexts) == bestContexts && (!negated || !allowed) {
+			continue
+		}
+
+		best, bestSpecificity, bestContexts, allowed = permission, specificity, len(entryContexts), !negated
+	}
+
+	return allowed, best, bestSpecificity >= 0
+}
+
+// permissionSpecificity returns how specifically the pattern matches the node,
+// or -1 if it does not match it. Both are expected to be lowercase.
+func permissionSpecificity(pattern, node string) int {
+	if pattern == "*" {
+		return 0
+	} else if prefix, ok := strings.CutSuffix(pattern, ".*"); ok {
+		if !strings.HasPrefix(node, prefix+".") {
+			return -1
+		}
+
+		return 2 * (strings.Count(prefix, ".") + 1)
+	} else if pattern == node {
+		return 2*(strings.Count(node, ".")+1) + 1
+	}
+
+	return -1
+}
+
+// validatePermission returns an error if the permission is not a valid node
+// optionally followed by its contexts.
+func validatePermission(permission string) error {
+	if permission == "" {
+		return fmt.Errorf("%w: no permission provided", ErrInvalidPermission)
+	} else if strings.ContainsFunc(permission, unicode.IsSpace) {
+		return fmt.Errorf("%w: permission cannot contain spaces", ErrInvalidPermission)
+	}
+
+	if rawNode, raw, ok := strings.Cut(permission, model.ContextSeparator); ok {
+		for _, pair := range strings.Split(raw, ",") {
+			if k, v, ok := strings.Cut(pair, "="); !ok || k == "" || v == "" {
+				return fmt.Errorf("%w: context '%s' is not a key=value pair", ErrInvalidPermission, pair)
+			} else if strings.ContainsAny(v, "="+model.ContextSeparator) {
+				return fmt.Errorf("%w: context '%s' has an invalid value", ErrInvalidPermission, pair)
+			}
+		}
+
+		permission = rawNode
+	}
+
+	node := strings.TrimPrefix(permission, "-")
+	if node == "" || strings.HasPrefix(node, "-") {
+		return fmt.Errorf("%w: permission has no node", ErrInvalidPermission)
+	} else if strings.Contains(strings.TrimSuffix(node, "*"), "*") || (node != "*" && strings.HasSuffix(node, "*") && !strings.HasSuffix(node, ".*")) {
+		return fmt.Errorf("%w: wildcards are only allowed as the last segment", ErrInvalidPermission)
+	} else if strings.HasPrefix(node, ".") || strings.HasSuffix(node, ".") || strings.Contains(node, "..") {
+		return fmt.Errorf("%w: permission has an empty segment", ErrInvalidPermission)
+	}
+
+	return nil
+}
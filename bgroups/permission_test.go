@@ -0,0 +1,93 @@
+package bgroups
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatchPermission(t *testing.T) {
+	lobby := map[string]string{"server": "lobby"}
+
+	tests := []struct {
+		name        string
+		permissions []string
+		node        string
+		contexts    map[string]string
+		allowed     bool
+		entry       string
+		ok          bool
+	}{
+		{"exact", []string{"kit.vip"}, "kit.vip", nil, true, "kit.vip", true},
+		{"wildcard", []string{"kit.*"}, "kit.vip", nil, true, "kit.*", true},
+		{"star", []string{"*"}, "kit.vip", nil, true, "*", true},
+		{"ignores case", []string{"Kit.VIP"}, "kit.Vip", nil, true, "Kit.VIP", true},
+		{"exact beats wildcard", []string{"-kit.*", "kit.vip"}, "kit.vip", nil, true, "kit.vip", true},
+		{"exact beats star", []string{"*", "-kit.vip"}, "kit.vip", nil, false, "-kit.vip", true},
+		{"wildcard beats star", []string{"-*", "kit.*"}, "kit.vip", nil, true, "kit.*", true},
+		{"longer wildcard wins", []string{"-kit.*", "kit.vip.*"}, "kit.vip.daily", nil, true, "kit.vip.*", true},
+		{"longer wildcard wins in any order", []string{"kit.vip.*", "-kit.*"}, "kit.vip.daily", nil, true, "kit.vip.*", true},
+		{"shorter wildcard loses", []string{"kit.*", "-kit.vip.*"}, "kit.vip.daily", nil, false, "-kit.vip.*", true},
+		{"negated beats allowed", []string{"kit.vip", "-kit.vip"}, "kit.vip", nil, false, "-kit.vip", true},
+		{"negated beats allowed in any order", []string{"-kit.vip", "kit.vip"}, "kit.vip", nil, false, "-kit.vip", true},
+		{"negated wildcard beats allowed wildcard", []string{"kit.*", "-kit.*"}, "kit.vip", nil, false, "-kit.*", true},
+		{"allow beats negated wildcard", []string{"-*", "kit.vip"}, "kit.vip", nil, true, "kit.vip", true},
+		{"context applies", []string{"kit.vip@server=lobby"}, "kit.vip", lobby, true, "kit.vip@server=lobby", true},
+		{"context does not apply", []string{"kit.vip@server=lobby"}, "kit.vip", map[string]string{"server": "bedwars"}, false, "", false},
+		{"more contexts win", []string{"-kit.vip", "kit.vip@server=lobby"}, "kit.vip", lobby, true, "kit.vip@server=lobby", true},
+		{"no match", []string{"kit.vip", "kit.vip.*"}, "kit.daily", nil, false, "", false},
+		{"wildcard needs a segment", []string{"kit.*"}, "kit", nil, false, "", false},
+		{"wildcard needs a whole segment", []string{"kit.*"}, "kits.vip", nil, false, "", false},
+		{"no permissions", nil, "kit.vip", nil, false, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, entry, ok := MatchPermission(tt.permissions, tt.node, tt.contexts)
+			if allowed != tt.allowed || entry != tt.entry || ok != tt.ok {
+				t.Errorf("MatchPermission = %v, %q, %v, want %v, %q, %v", allowed, entry, ok, tt.allowed, tt.entry, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidatePermission(t *testing.T) {
+	tests := []struct {
+		permission string
+		valid      bool
+	}{
+		{"kit.vip", true},
+		{"-kit.vip", true},
+		{"kit.*", true},
+		{"*", true},
+		{"-*", true},
+		{"kit.vip@server=lobby", true},
+		{"kit.vip@gamemode=bedwars,server=lobby", true},
+		{"", false},
+		{"kit vip", false},
+		{"kit.*.vip", false},
+		{"kit.v*", false},
+		{"*.vip", false},
+		{"--kit.vip", false},
+		{"-", false},
+		{".kit", false},
+		{"kit.", false},
+		{"kit..vip", false},
+		{"@server=lobby", false},
+		{"kit.vip@", false},
+		{"kit.vip@server", false},
+		{"kit.vip@=lobby", false},
+		{"kit.vip@server=", false},
+		{"kit.vip@server=lobby,", false},
+		{"kit.vip@server=a=b", false},
+		{"kit.vip@server=lobby@hub", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.permission, func(t *testing.T) {
+			err := validatePermission(tt.permission)
+			if tt.valid && err != nil {
+				t.Errorf("err = %v, want nil", err)
+			} else if !tt.valid && !errors.Is(err, ErrInvalidPermission) {
+				t.Errorf("err = %v, want ErrInvalidPermission", err)
+			}
+		})
+	}
+}
@@ -1,21 +1,32 @@
 package routes
 
 import (
+	"errors"
 	"github.com/Mides-Projects/Kyro/bgroups"
 	"github.com/Mides-Projects/Operator/helper"
 	"github.com/gofiber/fiber/v3"
 )
 
+// Create handles the creation of a group.
+// The actor query is who is recorded in the audit log.
 func Create(ctx fiber.Ctx) error {
 	if name := ctx.Params("name"); name == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"message": "No name provided",
 		})
+	} else if actor := ctx.Query("actor"); actor == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No actor provided",
+		})
 	} else if g := bgroups.Service().LookupByName(name); g != nil {
 		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"message": "Group with name '" + name + "' already exists",
 		})
-	} else if id, err := bgroups.Service().Insert(name); err != nil {
+	} else if id, err := bgroups.Service().Insert(name, actor); errors.Is(err, bgroups.ErrGroupNameTaken) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": "Group with name '" + name + "' already exists",
+		})
+	} else if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": helper.ServiceId + ": " + err.Error(),
 		})
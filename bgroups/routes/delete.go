@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/bgroups"
+	"github.com/Mides-Projects/Kyro/grants"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Delete handles the deletion of a group.
+// The policy query decides what happens to the grants referencing the group
+// and the actor query is who is recorded in the audit log.
+func Delete(ctx fiber.Ctx) error {
+	if id := ctx.Params("id"); id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No id provided",
+		})
+	} else if actor := ctx.Query("actor"); actor == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No actor provided",
+		})
+	} else if body, err := grants.Service().HandleGroupDelete(id, ctx.Query("policy", grants.PolicyReject), ctx.Query("target"), actor); errors.Is(err, bgroups.ErrGroupNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No such group found",
+		})
+	} else if errors.Is(err, grants.ErrInvalidPolicy) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	} else if errors.Is(err, grants.ErrGroupInUse) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": helper.ServiceId + ": " + err.Error(),
+		})
+	} else {
+		return ctx.Status(fiber.StatusOK).JSON(body)
+	}
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/bgroups"
+	"github.com/Mides-Projects/Kyro/grants"
+	"github.com/Mides-Projects/Kyro/grants/model"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/gofiber/fiber/v3"
+	"strconv"
+)
+
+// Holders handles the paginated lookup of the players holding a group.
+// The state query selects the active grants (default), the expired ones or all of them.
+func Holders(ctx fiber.Ctx) error {
+	limit := grants.DefaultHoldersLimit
+	if raw := ctx.Query("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid limit provided",
+			})
+		}
+
+		limit = v
+	}
+
+	if id := ctx.Params("id"); id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No id provided",
+		})
+	} else if g := bgroups.Service().LookupByID(id); g == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No such group found",
+		})
+	} else if body, err := grants.Service().HandleHolders(model.KeyGroup, id, ctx.Query("state", grants.StateActive), ctx.Query("cursor"), limit); errors.Is(err, grants.ErrInvalidQuery) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": helper.ServiceId + ": " + err.Error(),
+		})
+	} else {
+		return ctx.Status(fiber.StatusOK).JSON(body)
+	}
+}
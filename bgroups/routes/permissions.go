@@ -0,0 +1,186 @@
+package routes
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/bgroups"
+	"github.com/Mides-Projects/Kyro/bgroups/model"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Permissions handles the retrieval of the permissions of a group.
+func Permissions(ctx fiber.Ctx) error {
+	if id := ctx.Params("id"); id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No id provided",
+		})
+	} else if g := bgroups.Service().LookupByID(id); g == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No such group found",
+		})
+	} else {
+		permissions := make([]fiber.Map, 0, len(g.Permissions()))
+		for _, permission := range g.Permissions() {
+			permissions = append(permissions, marshalPermission(permission))
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+			"id":          g.ID(),
+			"permissions": permissions,
+		})
+	}
+}
+
+// EffectivePermissions handles the retrieval of the permissions in effect for a group,
+// including the ones inherited from its parents, see bgroups.ServiceImpl.EffectivePermissions.
+// Every permission tells if it allows its node and the group defining it.
+func EffectivePermissions(ctx fiber.Ctx) error {
+	if id := ctx.Params("id"); id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No id provided",
+		})
+	} else if resolutions, err := bgroups.Service().EffectivePermissions(id); err != nil {
+		return permissionError(ctx, err)
+	} else {
+		permissions := make([]fiber.Map, 0, len(resolutions))
+		for _, r := range resolutions {
+			body := marshalPermission(r.Entry)
+			body["allowed"] = r.Allowed
+			body["group_id"] = r.GroupID
+
+			permissions = append(permissions, body)
+		}
+
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+			"id":          id,
+			"permissions": permissions,
+		})
+	}
+}
+
+// AddPermission handles the addition of a permission to a group.
+// The actor query is who is recorded in the audit log.
+func AddPermission(ctx fiber.Ctx) error {
+	var body map[string]interface{}
+	if id := ctx.Params("id"); id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No id provided",
+		})
+	} else if err := sonic.Unmarshal(ctx.Body(), &body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid body provided",
+		})
+	} else if actor := ctx.Query("actor"); actor == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No actor provided",
+		})
+	} else if node, ok := body["permission"].(string); !ok {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No permission provided",
+		})
+	} else if contexts, ok := toContexts(body["contexts"]); !ok {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid contexts provided",
+		})
+	} else if permission := model.FormatPermission(node, contexts); permission == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No permission provided",
+		})
+	} else if added, err := bgroups.Service().AddPermission(id, permission, actor); err != nil {
+		return permissionError(ctx, err)
+	} else if !added {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": "Group already has permission '" + permission + "'",
+		})
+	} else {
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+			"id":         id,
+			"permission": permission,
+		})
+	}
+}
+
+// RemovePermission handles the removal of a permission from a group.
+// The permission is expected in its stored form, including its contexts,
+// and the actor query is who is recorded in the audit log.
+func RemovePermission(ctx fiber.Ctx) error {
+	if id := ctx.Params("id"); id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No id provided",
+		})
+	} else if permission := ctx.Query("permission"); permission == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No permission provided",
+		})
+	} else if actor := ctx.Query("actor"); actor == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No actor provided",
+		})
+	} else if removed, err := bgroups.Service().RemovePermission(id, permission, actor); err != nil {
+		return permissionError(ctx, err)
+	} else if !removed {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Group does not have permission '" + permission + "'",
+		})
+	} else {
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+			"id":         id,
+			"permission": permission,
+		})
+	}
+}
+
+// marshalPermission returns the stored permission split into its node and contexts.
+func marshalPermission(permission string) fiber.Map {
+	node, contexts := model.ParsePermission(permission)
+	if contexts == nil {
+		contexts = map[string]string{}
+	}
+
+	return fiber.Map{
+		"permission": permission,
+		"node":       node,
+		"contexts":   contexts,
+	}
+}
+
+// toContexts converts the contexts of a request body to a map.
+func toContexts(v interface{}) (map[string]string, bool) {
+	if v == nil {
+		return nil, true
+	}
+
+	raw, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	contexts := make(map[string]string, len(raw))
+	for k, value := range raw {
+		if s, ok := value.(string); !ok {
+			return nil, false
+		} else {
+			contexts[k] = s
+		}
+	}
+
+	return contexts, true
+}
+
+// permissionError writes the response for an error returned by a permission change.
+func permissionError(ctx fiber.Ctx, err error) error {
+	if errors.Is(err, bgroups.ErrGroupNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No such group found",
+		})
+	} else if errors.Is(err, bgroups.ErrInvalidPermission) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"message": helper.ServiceId + ": " + err.Error(),
+	})
+}
@@ -1,22 +1,106 @@
 package routes
 
 import (
+    "errors"
     "github.com/Mides-Projects/Kyro/bgroups"
+    "github.com/Mides-Projects/Kyro/bgroups/model"
+    "github.com/Mides-Projects/Operator/helper"
     "github.com/gofiber/fiber/v3"
+    "maps"
+    "slices"
+    "strconv"
+    "strings"
 )
 
-// Retrieve handles the retrieval of all groups.
+// groupFields are the fields of a marshaled group that can be projected,
+// with the value of each field when model.Group.Marshal omits it.
+var groupFields = map[string]interface{}{
+    "_id":          "",
+    "name":         "",
+    "display_name": "",
+    "char_color":   "",
+    "prefix":       "",
+    "suffix":       "",
+    "chat_prefix":  "",
+    "chat_suffix":  "",
+    "weight":       0,
+    "permissions":  []string{},
+    "parents":      []string{},
+}
+
+// Retrieve handles the paginated retrieval of the groups.
+// The sort query orders them by name or weight, the prefix query only keeps the
+// groups whose name starts with it and the fields query is a comma separated list
+// of the fields returned for each group besides its ID, every field if empty.
+// The response is always an array of groups with the cursor of the next page,
+// empty on the last page, and every group has each of the returned fields.
 func Retrieve(ctx fiber.Ctx) error {
-    body := map[string]interface{}{}
-    for _, g := range bgroups.Service().Values() {
-        body[g.ID()] = g.Marshal()
+    limit := bgroups.DefaultListLimit
+    if raw := ctx.Query("limit"); raw != "" {
+        v, err := strconv.Atoi(raw)
+        if err != nil {
+            return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "message": "Invalid limit provided",
+            })
+        }
+
+        limit = v
     }
 
-    if len(body) == 0 {
-        return ctx.Status(fiber.StatusNoContent).JSON(fiber.Map{
-            "message": "No groups found",
+    fields := slices.Collect(maps.Keys(groupFields))
+    if raw := ctx.Query("fields"); raw != "" {
+        fields = []string{"_id"}
+        for _, field := range strings.Split(raw, ",") {
+            if _, ok := groupFields[field]; !ok {
+                return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "message": "Unknown field '" + field + "' provided",
+                })
+            }
+
+            fields = append(fields, field)
+        }
+    }
+
+    groups, next, err := bgroups.Service().List(bgroups.ListQuery{
+        Sort:   ctx.Query("sort"),
+        Prefix: ctx.Query("prefix"),
+        Cursor: ctx.Query("cursor"),
+        Limit:  limit,
+    })
+    if errors.Is(err, bgroups.ErrInvalidQuery) {
+        return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "message": err.Error(),
+        })
+    } else if err != nil {
+        return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "message": helper.ServiceId + ": " + err.Error(),
         })
     }
 
-    return ctx.Status(fiber.StatusOK).JSON(body)
+    values := make([]map[string]interface{}, 0, len(groups))
+    for _, g := range groups {
+        values = append(values, projectGroup(g, fields))
+    }
+
+    return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+        "groups": values,
+        "next":   next,
+    })
+}
+
+// projectGroup returns the given fields of the marshaled group,
+// including the ones model.Group.Marshal omits because they are empty.
+func projectGroup(g *model.Group, fields []string) map[string]interface{} {
+    body := g.Marshal()
+
+    projected := make(map[string]interface{}, len(fields))
+    for _, field := range fields {
+        if v, ok := body[field]; ok {
+            projected[field] = v
+        } else {
+            projected[field] = groupFields[field]
+        }
+    }
+
+    return projected
 }
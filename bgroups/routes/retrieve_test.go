@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/Mides-Projects/Kyro/bgroups/model"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestProjectGroup(t *testing.T) {
+	g := model.NewGroup("member", "Member")
+
+	body := projectGroup(g, []string{"_id", "weight", "prefix", "permissions"})
+	if keys := slices.Sorted(maps.Keys(body)); !slices.Equal(keys, []string{"_id", "permissions", "prefix", "weight"}) {
+		t.Fatalf("fields = %v, want every requested field", keys)
+	} else if body["_id"] != "member" || body["weight"] != 0 || body["prefix"] != "" {
+		t.Errorf("body = %v, want the ID and the zero values", body)
+	} else if permissions, ok := body["permissions"].([]string); !ok || len(permissions) != 0 {
+		t.Errorf("permissions = %v, want an empty array", body["permissions"])
+	}
+
+	g.SetWeight(10)
+	if body = projectGroup(g, []string{"weight"}); body["weight"] != 10 {
+		t.Errorf("weight = %v, want 10", body["weight"])
+	}
+
+	if body = projectGroup(g, slices.Collect(maps.Keys(groupFields))); len(body) != len(groupFields) {
+		t.Errorf("fields = %d, want all %d", len(body), len(groupFields))
+	}
+}
@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/bgroups"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Update handles the partial update of a group.
+// The actor query is who is recorded in the audit log.
+func Update(ctx fiber.Ctx) error {
+	var patch map[string]interface{}
+	if id := ctx.Params("id"); id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No id provided",
+		})
+	} else if err := sonic.Unmarshal(ctx.Body(), &patch); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid body provided",
+		})
+	} else if actor := ctx.Query("actor"); actor == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No actor provided",
+		})
+	} else if g, err := bgroups.Service().Update(id, patch, actor); errors.Is(err, bgroups.ErrGroupNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No such group found",
+		})
+	} else if errors.Is(err, bgroups.ErrGroupNameTaken) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": "Group with name '" + patch["name"].(string) + "' already exists",
+		})
+	} else if errors.Is(err, bgroups.ErrGroupCycle) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	} else if errors.Is(err, bgroups.ErrInvalidPatch) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": helper.ServiceId + ": " + err.Error(),
+		})
+	} else {
+		return ctx.Status(fiber.StatusOK).JSON(g.Marshal())
+	}
+}
@@ -1,15 +1,17 @@
 package bgroups
 
 import (
-	"context"
 	"errors"
+	"fmt"
+	"github.com/Mides-Projects/Kyro/audit"
 	"github.com/Mides-Projects/Kyro/bgroups/model"
 	"github.com/Mides-Projects/Operator/helper"
 	"github.com/bytedance/sonic"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -21,8 +23,14 @@ type ServiceImpl struct {
 	ids   map[string]string
 	idsMu sync.RWMutex
 
-	col *mongo.Collection
-	ctx context.Context
+	// writeMu serializes the changes so they are persisted and announced
+	// to the other instances in the same order.
+	writeMu sync.Mutex
+
+	// store persists the groups, see GroupStore.
+	store GroupStore
+
+	deps Dependencies
 }
 
 // cache caches the group information.
@@ -36,6 +44,50 @@ func (s *ServiceImpl) cache(g *model.Group) {
 	s.idsMu.Unlock()
 }
 
+// reserveName reserves the name of the new group unless another cached group has the same name,
+// ignoring case. The group itself is only cached once persisted, so lookups by name
+// find nothing until then. It returns false if the name is taken.
+func (s *ServiceImpl) reserveName(g *model.Group) bool {
+	s.idsMu.Lock()
+	defer s.idsMu.Unlock()
+
+	if _, ok := s.ids[strings.ToLower(g.Name())]; ok {
+		return false
+	}
+	s.ids[strings.ToLower(g.Name())] = g.ID()
+
+	return true
+}
+
+// replace replaces the cached group with its new version at once,
+// so readers get either the old or the new version but never a partial one.
+func (s *ServiceImpl) replace(old, g *model.Group) {
+	s.idsMu.Lock()
+	defer s.idsMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ids[strings.ToLower(old.Name())] == old.ID() {
+		delete(s.ids, strings.ToLower(old.Name()))
+	}
+	s.values[g.ID()] = g
+	s.ids[strings.ToLower(g.Name())] = g.ID()
+}
+
+// uncache removes the group information from the cache.
+func (s *ServiceImpl) uncache(g *model.Group) {
+	s.mu.Lock()
+	delete(s.values, g.ID())
+	s.mu.Unlock()
+
+	s.idsMu.Lock()
+	if s.ids[strings.ToLower(g.Name())] == g.ID() {
+		delete(s.ids, strings.ToLower(g.Name()))
+	}
+	s.idsMu.Unlock()
+}
+
 // Values returns all the groups.
 func (s *ServiceImpl) Values() []*model.Group {
 	s.mu.RLock()
@@ -69,95 +121,551 @@ func (s *ServiceImpl) LookupByName(name string) *model.Group {
 	return nil
 }
 
-// Insert inserts a new group with the given ID and name.
-func (s *ServiceImpl) Insert(name string) (string, error) {
-	if s.col == nil {
-		return "", errors.New(helper.ServiceId + ": no MongoDB collection")
+// Insert inserts a new group with the given name and returns its ID.
+// The group is only kept and announced once it has been persisted.
+// It returns ErrGroupNameTaken if a group with the same name exists, ignoring case,
+// even if it was just created by another instance. The actor is recorded in the audit log,
+// and ErrNoActor is returned without one.
+func (s *ServiceImpl) Insert(name, actor string) (string, error) {
+	if s.store == nil {
+		return "", errors.New(helper.ServiceId + ": no group store")
+	} else if actor == "" {
+		return "", ErrNoActor
 	}
 
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	g := model.NewGroup(uuid.New().String(), name)
-	s.cache(g)
+	if !s.reserveName(g) {
+		return "", ErrGroupNameTaken
+	}
 
-	go func() {
-		// Insert the group into the MongoDB collection.
-		if _, err := s.col.InsertOne(s.ctx, g.Marshal()); err != nil {
-			panic(err)
-		}
+	// Persist the group before anyone can see it, and release its name if that fails.
+	if err := s.store.Insert(g); err != nil {
+		s.uncache(g)
+
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.values[g.ID()] = g
+	s.mu.Unlock()
+
+	s.deps.Publish(
+		SubjectCreateGroup,
+		map[string]interface{}{
+			"service_id": helper.ServiceId,
+			"id":         g.ID(),
+			"body":       g.Marshal(),
+		},
+	)
 
-		helper.PublishNats(
-			SubjectCreateGroup,
-			map[string]interface{}{
-				"service_id": helper.ServiceId,
-				"name":       g.Name(),
-				"id":         g.ID(),
-			},
-		)
-	}()
+	s.deps.Audit.Record(audit.ActionGroupCreate, actor, "", g.ID(), nil, g.Marshal())
 
 	helper.Log.Info(helper.ServiceId+": successfully created group", "id", g.ID(), "name", name)
 
 	return g.ID(), nil
 }
 
+// Update applies the given partial changes to the group with the given ID.
+// Only the fields present in the patch are changed, the rest are kept as they are.
+// The changes are applied to a copy of the cached group which then replaces it,
+// because the cached group may be read concurrently. The actor is recorded in the audit log,
+// and ErrNoActor is returned without one.
+func (s *ServiceImpl) Update(id string, patch map[string]interface{}, actor string) (*model.Group, error) {
+	if s.store == nil {
+		return nil, errors.New(helper.ServiceId + ": no group store")
+	} else if actor == "" {
+		return nil, ErrNoActor
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	g := s.LookupByID(id)
+	if g == nil {
+		return nil, ErrGroupNotFound
+	} else if len(patch) == 0 {
+		return nil, fmt.Errorf("%w: no fields provided", ErrInvalidPatch)
+	}
+
+	set := map[string]interface{}{}
+	for field, v := range patch {
+		switch field {
+		case "parents":
+			parents, err := s.validateParents(id, v)
+			if err != nil {
+				return nil, err
+			}
+
+			set[field] = parents
+		case "weight":
+			weight, ok := v.(float64)
+			if !ok || weight != math.Trunc(weight) {
+				return nil, fmt.Errorf("%w: 'weight' is not an integer", ErrInvalidPatch)
+			}
+
+			set[field] = int(weight)
+		default:
+			value, ok := v.(string)
+			if _, known := patchSetters[field]; !known {
+				return nil, fmt.Errorf("%w: unknown field '%s'", ErrInvalidPatch, field)
+			} else if !ok {
+				return nil, fmt.Errorf("%w: '%s' is not a string", ErrInvalidPatch, field)
+			} else if field == "name" && value == "" {
+				return nil, fmt.Errorf("%w: name cannot be empty", ErrInvalidPatch)
+			} else if field == "name" {
+				if other := s.LookupByName(value); other != nil && other.ID() != id {
+					return nil, ErrGroupNameTaken
+				}
+			}
+
+			set[field] = value
+		}
+	}
+
+	return s.update(g, set, actor)
+}
+
+// update persists the validated marshaled fields of the group, replaces the cached group
+// with an updated copy and announces it. The caller must hold writeMu.
+func (s *ServiceImpl) update(g *model.Group, set map[string]interface{}, actor string) (*model.Group, error) {
+	if err := s.store.Update(g.ID(), set); err != nil {
+		return nil, err
+	}
+
+	before := g.Marshal()
+	updated := g.Clone()
+	for field, value := range set {
+		switch field {
+		case "parents":
+			updated.SetParents(value.([]string))
+		case "weight":
+			updated.SetWeight(value.(int))
+		default:
+			patchSetters[field](updated, value.(string))
+		}
+	}
+
+	s.replace(g, updated)
+	g = updated
+
+	s.deps.Publish(
+		SubjectUpdateGroup,
+		map[string]interface{}{
+			"service_id": helper.ServiceId,
+			"id":         g.ID(),
+			"body":       g.Marshal(),
+		},
+	)
+
+	s.deps.Audit.Record(audit.ActionGroupUpdate, actor, "", g.ID(), before, g.Marshal())
+
+	helper.Log.Info(helper.ServiceId+": successfully updated group", "id", g.ID(), "name", g.Name())
+
+	return g, nil
+}
+
+// validateParents returns the parent IDs of the patch if they all exist
+// and none of them inherits, directly or not, from the group with the given ID.
+func (s *ServiceImpl) validateParents(id string, v interface{}) ([]string, error) {
+	values, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: 'parents' is not an array", ErrInvalidPatch)
+	}
+
+	parents := make([]string, 0, len(values))
+	for _, value := range values {
+		parentID, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: parent is not a string", ErrInvalidPatch)
+		} else if parentID == id {
+			return nil, fmt.Errorf("%w: group cannot inherit from itself", ErrGroupCycle)
+		} else if s.LookupByID(parentID) == nil {
+			return nil, fmt.Errorf("%w: parent '%s' does not exist", ErrInvalidPatch, parentID)
+		} else if slices.Contains(parents, parentID) {
+			continue
+		} else if s.inherits(parentID, id) {
+			return nil, fmt.Errorf("%w: parent '%s' already inherits from the group", ErrGroupCycle, parentID)
+		}
+
+		parents = append(parents, parentID)
+	}
+
+	return parents, nil
+}
+
+// inherits returns if the group with the given ID inherits, directly or not, from the ancestor.
+func (s *ServiceImpl) inherits(id, ancestorID string) bool {
+	found := false
+	s.walk(id, func(g *model.Group) bool {
+		found = g.ID() == ancestorID
+
+		return !found
+	})
+
+	return found
+}
+
+// walk visits the group with the given ID and then its ancestors breadth-first,
+// visiting each group once, until fn returns false. Missing parents are skipped.
+func (s *ServiceImpl) walk(id string, fn func(g *model.Group) bool) {
+	visited := map[string]bool{id: true}
+	queue := []string{id}
+
+	for len(queue) > 0 {
+		g := s.LookupByID(queue[0])
+		queue = queue[1:]
+
+		if g == nil {
+			continue
+		} else if !fn(g) {
+			return
+		}
+
+		for _, parentID := range g.Parents() {
+			if !visited[parentID] {
+				visited[parentID] = true
+				queue = append(queue, parentID)
+			}
+		}
+	}
+}
+
+// EffectivePermissions returns the permission entries of the group with the given ID and of
+// every group it inherits from that are in effect, closest groups first. An entry is in effect
+// if ResolvePermission decides its own node in its own contexts with it, so the entries
+// overridden by a closer group or by a more specific entry are left out.
+func (s *ServiceImpl) EffectivePermissions(id string) ([]*Resolution, error) {
+	if s.LookupByID(id) == nil {
+		return nil, ErrGroupNotFound
+	}
+
+	resolutions := []*Resolution{}
+	s.walk(id, func(g *model.Group) bool {
+		for _, permission := range g.Permissions() {
+			entry, contexts := model.ParsePermission(permission)
+
+			r := s.ResolvePermission(id, strings.TrimPrefix(entry, "-"), contexts)
+			if r != nil && r.GroupID == g.ID() && r.Entry == permission {
+				resolutions = append(resolutions, r)
+			}
+		}
+
+		return true
+	})
+
+	return resolutions, nil
+}
+
+// ResolvePermission resolves the node in the group with the given ID and its ancestors
+// using MatchPermission for the caller contexts. The closest group in the hierarchy with a matching entry decides,
+// so a group overrides what it inherits. It returns nil if no group matches the node.
+func (s *ServiceImpl) ResolvePermission(id, node string, contexts map[string]string) *Resolution {
+	var resolution *Resolution
+	s.walk(id, func(g *model.Group) bool {
+		if allowed, entry, ok := MatchPermission(g.Permissions(), node, contexts); ok {
+			resolution = &Resolution{
+				Allowed: allowed,
+				GroupID: g.ID(),
+				Entry:   entry,
+			}
+		}
+
+		return resolution == nil
+	})
+
+	return resolution
+}
+
+// Delete deletes the group with the given ID from the cache and the store.
+// The group is first removed from the parents of the groups inheriting from it,
+// so a failure never leaves a group inheriting from a deleted one.
+// Grants referencing the group are not touched, see grants.ServiceImpl.HandleGroupDelete.
+// The actor is recorded in the audit log, including for the groups it was removed from,
+// and ErrNoActor is returned without one.
+func (s *ServiceImpl) Delete(id, actor string) error {
+	if s.store == nil {
+		return errors.New(helper.ServiceId + ": no group store")
+	} else if actor == "" {
+		return ErrNoActor
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	g := s.LookupByID(id)
+	if g == nil {
+		return ErrGroupNotFound
+	}
+
+	for _, child := range s.Values() {
+		if !slices.Contains(child.Parents(), id) {
+			continue
+		}
+
+		parents := slices.DeleteFunc(slices.Clone(child.Parents()), func(parentID string) bool {
+			return parentID == id
+		})
+		if _, err := s.update(child, map[string]interface{}{"parents": parents}, actor); err != nil {
+			return err
+		}
+	}
+
+	if err := s.store.Delete(id); err != nil {
+		return err
+	}
+
+	s.uncache(g)
+
+	s.deps.Publish(
+		SubjectDeleteGroup,
+		map[string]interface{}{
+			"service_id": helper.ServiceId,
+			"id":         g.ID(),
+			"body":       g.Marshal(),
+		},
+	)
+
+	s.deps.Audit.Record(audit.ActionGroupDelete, actor, "", g.ID(), g.Marshal(), nil)
+
+	helper.Log.Info(helper.ServiceId+": successfully deleted group", "id", g.ID(), "name", g.Name())
+
+	return nil
+}
+
+// AddPermission adds the permission to the group with the given ID.
+// The permission may be limited to contexts, see model.FormatPermission.
+// It returns false if the group already has the permission.
+// The actor is recorded in the audit log, and ErrNoActor is returned without one.
+func (s *ServiceImpl) AddPermission(id, permission, actor string) (bool, error) {
+	if s.store == nil {
+		return false, errors.New(helper.ServiceId + ": no group store")
+	} else if actor == "" {
+		return false, ErrNoActor
+	} else if err := validatePermission(permission); err != nil {
+		return false, err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	permission = model.FormatPermission(model.ParsePermission(permission))
+
+	g := s.LookupByID(id)
+	if g == nil {
+		return false, ErrGroupNotFound
+	} else if storedPermission(g, permission) != "" {
+		return false, nil
+	}
+
+	if err := s.store.AddPermission(id, permission); err != nil {
+		return false, err
+	}
+
+	// Change a copy, because the cached group may be read concurrently.
+	before := g.Marshal()
+	updated := g.Clone()
+	updated.AddPermission(permission)
+	s.replace(g, updated)
+	g = updated
+
+	s.publishPermissions(g)
+
+	s.deps.Audit.Record(audit.ActionGroupAddPermission, actor, "", g.ID(), before, g.Marshal())
+
+	helper.Log.Info(helper.ServiceId+": successfully added permission", "id", g.ID(), "permission", permission)
+
+	return true, nil
+}
+
+// RemovePermission removes the permission from the group with the given ID.
+// It returns false if the group does not have the permission.
+// The actor is recorded in the audit log, and ErrNoActor is returned without one.
+func (s *ServiceImpl) RemovePermission(id, permission, actor string) (bool, error) {
+	if s.store == nil {
+		return false, errors.New(helper.ServiceId + ": no group store")
+	} else if actor == "" {
+		return false, ErrNoActor
+	} else if err := validatePermission(permission); err != nil {
+		return false, err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	permission = model.FormatPermission(model.ParsePermission(permission))
+
+	g := s.LookupByID(id)
+	if g == nil {
+		return false, ErrGroupNotFound
+	} else if permission = storedPermission(g, permission); permission == "" {
+		return false, nil
+	}
+
+	if err := s.store.RemovePermission(id, permission); err != nil {
+		return false, err
+	}
+
+	// Change a copy, because the cached group may be read concurrently.
+	before := g.Marshal()
+	updated := g.Clone()
+	updated.RemovePermission(permission)
+	s.replace(g, updated)
+	g = updated
+
+	s.publishPermissions(g)
+
+	s.deps.Audit.Record(audit.ActionGroupRemovePermission, actor, "", g.ID(), before, g.Marshal())
+
+	helper.Log.Info(helper.ServiceId+": successfully removed permission", "id", g.ID(), "permission", permission)
+
+	return true, nil
+}
+
+// storedPermission returns the permission of the group equal to the given one ignoring case,
+// because the permissions stored before nodes were lowercased may differ in case.
+// It returns an empty string if the group does not have the permission.
+func storedPermission(g *model.Group, permission string) string {
+	for _, stored := range g.Permissions() {
+		if strings.EqualFold(stored, permission) {
+			return stored
+		}
+	}
+
+	return ""
+}
+
+// publishPermissions announces the permissions change of the group to the other instances.
+// It is called while holding writeMu so the changes are announced in order.
+func (s *ServiceImpl) publishPermissions(g *model.Group) {
+	s.deps.Publish(
+		SubjectPermissionsGroup,
+		map[string]interface{}{
+			"service_id": helper.ServiceId,
+			"id":         g.ID(),
+			"body":       g.Marshal(),
+		},
+	)
+}
+
+// load caches every group of the store.
+func (s *ServiceImpl) load() error {
+	groups, err := s.store.All()
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		s.cache(g)
+	}
+
+	return nil
+}
+
 // Hook initializes the group service.
+// The audit service must be hooked first so no change goes unrecorded.
 func (s *ServiceImpl) Hook() error {
-	if s.col != nil {
-		return errors.New(helper.ServiceId + ": collection already set")
+	if s.store != nil {
+		return errors.New(helper.ServiceId + ": store already set")
 	} else if helper.NatsClient == nil {
 		return errors.New(helper.ServiceId + ": nats client not set")
+	} else if !s.deps.Audit.Hooked() {
+		return errors.New(helper.ServiceId + ": audit service not hooked")
 	}
 
-	s.col = helper.MongoClient.Database("kyro").Collection("groups")
-	// caching the context helps a lot with performance and memory usage
-	s.ctx = context.Background()
-
-	cur, err := s.col.Find(s.ctx, bson.M{})
+	store, err := newStore()
 	if err != nil {
+		return errors.Join(errors.New(helper.ServiceId+": failed to open the group store"), err)
+	}
+
+	s.store = store
+	if err = s.load(); err != nil {
 		return err
 	}
 
-	for cur.Next(s.ctx) {
-		var body map[string]interface{}
-		g := &model.Group{}
+	helper.Log.Info(helper.ServiceId + ": successfully loaded " + strconv.Itoa(len(s.values)) + " group(s) from the database!")
 
-		if err = cur.Decode(&body); err != nil {
-			helper.Log.Error(helper.ServiceId+": failed to decode group", "error", err)
-		} else if err = g.Unmarshal(body); err != nil {
-			helper.Log.Error(helper.ServiceId+": failed to unmarshal group", "error", err, "body", body)
-		} else {
-			s.cache(g)
+	// Every change is published with the full group so the replicas can converge
+	// on the same cache no matter which messages they missed before.
+	for _, subject := range []string{SubjectCreateGroup, SubjectUpdateGroup, SubjectPermissionsGroup} {
+		if _, err := helper.NatsClient.Subscribe(subject, s.natsUpsertGroup); err != nil {
+			return errors.Join(errors.New(helper.ServiceId+": failed to subscribe to "+subject), err)
 		}
 	}
 
-	helper.Log.Info(helper.ServiceId + ": successfully loaded " + string(len(s.values)) + " group(s) from the database!")
-
-	if _, err := helper.NatsClient.Subscribe(SubjectCreateGroup, s.natsCreateGroup); err != nil {
-		return errors.Join(errors.New(helper.ServiceId+": failed to subscribe to create group"), err)
+	if _, err := helper.NatsClient.Subscribe(SubjectDeleteGroup, s.natsDeleteGroup); err != nil {
+		return errors.Join(errors.New(helper.ServiceId+": failed to subscribe to delete group"), err)
 	}
 
 	return nil
 }
 
-// Service provides group management.
-func (s *ServiceImpl) natsCreateGroup(msg *nats.Msg) {
+// natsUpsertGroup replaces the cached group with the one created or updated by another instance.
+func (s *ServiceImpl) natsUpsertGroup(msg *nats.Msg) {
 	var body map[string]interface{}
 	if err := sonic.Unmarshal(msg.Data, &body); err != nil {
-		helper.Log.Error("nats: failed to unmarshal create group message", "err", err)
+		helper.Log.Error("nats: failed to unmarshal group message", "subject", msg.Subject, "err", err)
 	} else if servID, ok := body["service_id"].(string); !ok {
-		helper.Log.Error("nats: create group message missing service ID")
+		helper.Log.Error("nats: group message missing service ID", "subject", msg.Subject)
 	} else if servID == helper.ServiceId {
-		helper.Log.Info("nats: Ignoring create group message from self")
-	} else if id, ok := body["id"].(string); !ok {
-		helper.Log.Error("nats: create group message missing ID")
-	} else if name, ok := body["name"].(string); !ok {
-		helper.Log.Error("nats: create group message missing name")
+		return // The cache was already updated by this instance.
+	} else if groupBody, ok := body["body"].(map[string]interface{}); !ok {
+		helper.Log.Error("nats: group message missing body", "subject", msg.Subject)
 	} else {
-		s.cache(model.NewGroup(id, name))
+		g := &model.Group{}
+		if err = g.Unmarshal(groupBody); err != nil {
+			helper.Log.Error("nats: failed to unmarshal group", "subject", msg.Subject, "err", err, "body", groupBody)
 
-		helper.Log.Info("nats: successfully created group", "id", id, "name", name)
+			return
+		}
+
+		if old := s.LookupByID(g.ID()); old != nil {
+			s.replace(old, g)
+		} else {
+			s.cache(g)
+		}
+
+		helper.Log.Info("nats: successfully synchronized group", "subject", msg.Subject, "id", g.ID(), "name", g.Name())
+	}
+}
+
+// natsDeleteGroup removes the group deleted by another instance from the cache.
+func (s *ServiceImpl) natsDeleteGroup(msg *nats.Msg) {
+	var body map[string]interface{}
+	if err := sonic.Unmarshal(msg.Data, &body); err != nil {
+		helper.Log.Error("nats: failed to unmarshal delete group message", "err", err)
+	} else if servID, ok := body["service_id"].(string); !ok {
+		helper.Log.Error("nats: delete group message missing service ID")
+	} else if servID == helper.ServiceId {
+		return // The group was already deleted by this instance.
+	} else if id, ok := body["id"].(string); !ok {
+		helper.Log.Error("nats: delete group message missing ID")
+	} else if g := s.LookupByID(id); g != nil {
+		s.uncache(g)
+
+		helper.Log.Info("nats: successfully deleted group", "id", id, "name", g.Name())
 	}
 }
 
+// NewService returns a service backed by the given store with its groups already loaded.
+// Unlike Hook it neither connects to MongoDB nor subscribes to NATS, which makes it
+// suitable for tests and tools embedding the groups service, see MemoryStore.
+func NewService(store GroupStore, deps Dependencies) (*ServiceImpl, error) {
+	s := &ServiceImpl{
+		values: make(map[string]*model.Group),
+		ids:    make(map[string]string),
+		store:  store,
+		deps:   deps.withDefaults(),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
 func Service() *ServiceImpl {
 	return service
 }
@@ -165,6 +673,41 @@ func Service() *ServiceImpl {
 var service = &ServiceImpl{
 	values: make(map[string]*model.Group),
 	ids:    make(map[string]string),
+	deps:   Dependencies{}.withDefaults(),
 }
 
-var SubjectCreateGroup = "kyro:create_group"
+var (
+	SubjectCreateGroup = "kyro:create_group"
+	SubjectUpdateGroup = "kyro:update_group"
+	SubjectDeleteGroup = "kyro:delete_group"
+
+	SubjectPermissionsGroup = "kyro:permissions_group"
+)
+
+var (
+	// ErrGroupNotFound is returned when no group exists with the given ID.
+	ErrGroupNotFound = errors.New("group not found")
+	// ErrGroupNameTaken is returned when another group already uses the given name.
+	ErrGroupNameTaken = errors.New("group name already taken")
+	// ErrInvalidPatch is returned when a group update contains invalid fields.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrGroupCycle is returned when a group would end up inheriting from itself.
+	ErrGroupCycle = errors.New("group inheritance cycle")
+	// ErrInvalidPermission is returned when a permission node is not valid.
+	ErrInvalidPermission = errors.New("invalid permission")
+	// ErrInvalidQuery is returned when the groups are listed with invalid input.
+	ErrInvalidQuery = errors.New("invalid query")
+	// ErrNoActor is returned when a change is requested without an actor to record in the audit log.
+	ErrNoActor = errors.New("no actor provided")
+)
+
+// patchSetters maps the string fields accepted by Update to the setter of the group.
+var patchSetters = map[string]func(g *model.Group, value string){
+	"name":         (*model.Group).SetName,
+	"display_name": (*model.Group).SetDisplayName,
+	"char_color":   (*model.Group).SetCharColor,
+	"prefix":       (*model.Group).SetPrefix,
+	"suffix":       (*model.Group).SetSuffix,
+	"chat_prefix":  (*model.Group).SetChatPrefix,
+	"chat_suffix":  (*model.Group).SetChatSuffix,
+}
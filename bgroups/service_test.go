@@ -0,0 +1,516 @@
+package bgroups
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/audit"
+	"github.com/Mides-Projects/Kyro/bgroups/model"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// newTestService returns a group service backed by a MemoryStore, running without MongoDB or NATS.
+func newTestService(t *testing.T) (*ServiceImpl, *MemoryStore, *audit.ServiceImpl) {
+	t.Helper()
+
+	store := NewMemoryStore()
+	auditService := audit.NewService(audit.NewMemoryStore())
+
+	s, err := NewService(store, Dependencies{
+		Audit:   auditService,
+		Publish: func(string, map[string]interface{}) {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, store, auditService
+}
+
+// stored returns the group with the given ID as persisted by the store.
+func stored(t *testing.T, store *MemoryStore, id string) *model.Group {
+	t.Helper()
+
+	groups, err := store.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, g := range groups {
+		if g.ID() == id {
+			return g
+		}
+	}
+
+	t.Fatalf("group %s is not stored", id)
+
+	return nil
+}
+
+func TestNewServiceLoadsStoredGroups(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Insert(model.NewGroup("admin", "Admin")); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewService(store, Dependencies{Publish: func(string, map[string]interface{}) {}})
+	if err != nil {
+		t.Fatal(err)
+	} else if g := s.LookupByName("ADMIN"); g == nil || g.ID() != "admin" {
+		t.Fatalf("LookupByName = %v, want admin", g)
+	}
+}
+
+func TestInsert(t *testing.T) {
+	s, store, auditService := newTestService(t)
+
+	id, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if g := s.LookupByID(id); g == nil || g.Name() != "Admin" {
+		t.Fatalf("LookupByID = %v, want Admin", g)
+	} else if g = s.LookupByName("admin"); g == nil || g.ID() != id {
+		t.Fatalf("LookupByName = %v, want %s", g, id)
+	}
+	stored(t, store, id)
+
+	entries, _, err := auditService.Query(audit.Filter{GroupID: id}, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 1 || entries[0].Action() != audit.ActionGroupCreate || entries[0].Actor() != "console" {
+		t.Errorf("audit entries = %d, want 1 group creation by console", len(entries))
+	}
+}
+
+func TestNameConflicts(t *testing.T) {
+	s, _, _ := newTestService(t)
+
+	adminID, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memberID, err := s.Insert("Member", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.Insert("ADMIN", "console"); !errors.Is(err, ErrGroupNameTaken) {
+		t.Errorf("Insert of a taken name err = %v, want ErrGroupNameTaken", err)
+	}
+	if _, err = s.Update(memberID, map[string]interface{}{"name": "admin"}, "console"); !errors.Is(err, ErrGroupNameTaken) {
+		t.Errorf("rename to a taken name err = %v, want ErrGroupNameTaken", err)
+	}
+
+	// Renaming a group to another case of its own name is not a conflict.
+	if _, err = s.Update(adminID, map[string]interface{}{"name": "ADMIN"}, "console"); err != nil {
+		t.Errorf("rename to another case err = %v", err)
+	}
+
+	// The old name is released by a rename.
+	if _, err = s.Update(memberID, map[string]interface{}{"name": "Default"}, "console"); err != nil {
+		t.Fatal(err)
+	} else if _, err = s.Insert("member", "console"); err != nil {
+		t.Errorf("Insert of a released name err = %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	s, store, _ := newTestService(t)
+
+	parentID, err := s.Insert("Member", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := s.Update(id, map[string]interface{}{
+		"name":    "Owner",
+		"prefix":  "[Owner]",
+		"weight":  float64(100),
+		"parents": []interface{}{parentID},
+	}, "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, got := range []*model.Group{g, s.LookupByID(id), stored(t, store, id)} {
+		if got.Name() != "Owner" || got.Prefix() != "[Owner]" || got.Weight() != 100 || !slices.Equal(got.Parents(), []string{parentID}) {
+			t.Errorf("group = %v, want the patch applied", got.Marshal())
+		}
+	}
+	if s.LookupByName("admin") != nil || s.LookupByName("owner") == nil {
+		t.Error("the names are not updated after a rename")
+	}
+}
+
+func TestUpdateRejectsInvalidPatch(t *testing.T) {
+	s, _, _ := newTestService(t)
+
+	id, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	childID, err := s.Insert("Helper", "console")
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err = s.Update(childID, map[string]interface{}{"parents": []interface{}{id}}, "console"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		patch map[string]interface{}
+		want  error
+	}{
+		{"empty", map[string]interface{}{}, ErrInvalidPatch},
+		{"unknown field", map[string]interface{}{"color": "red"}, ErrInvalidPatch},
+		{"empty name", map[string]interface{}{"name": ""}, ErrInvalidPatch},
+		{"fractional weight", map[string]interface{}{"weight": 1.5}, ErrInvalidPatch},
+		{"missing parent", map[string]interface{}{"parents": []interface{}{"missing"}}, ErrInvalidPatch},
+		{"self parent", map[string]interface{}{"parents": []interface{}{id}}, ErrGroupCycle},
+		{"cycle", map[string]interface{}{"parents": []interface{}{childID}}, ErrGroupCycle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Update(id, tt.patch, "console"); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err = s.Update("missing", map[string]interface{}{"name": "x"}, "console"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("update of a missing group err = %v, want ErrGroupNotFound", err)
+	}
+}
+
+func TestAddAndRemovePermission(t *testing.T) {
+	s, store, auditService := newTestService(t)
+
+	id, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if added, err := s.AddPermission(id, "Kit.VIP@Server=Lobby", "console"); err != nil || !added {
+		t.Fatalf("AddPermission = %v, %v, want true", added, err)
+	} else if added, err = s.AddPermission(id, "kit.vip@server=lobby", "console"); err != nil || added {
+		t.Errorf("AddPermission of the same permission = %v, %v, want false", added, err)
+	} else if _, err = s.AddPermission(id, "kit.*.vip", "console"); !errors.Is(err, ErrInvalidPermission) {
+		t.Errorf("AddPermission of an invalid permission err = %v, want ErrInvalidPermission", err)
+	} else if _, err = s.AddPermission("missing", "kit.vip", "console"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("AddPermission to a missing group err = %v, want ErrGroupNotFound", err)
+	}
+
+	want := []string{"kit.vip@server=lobby"}
+	if got := s.LookupByID(id).Permissions(); !slices.Equal(got, want) {
+		t.Errorf("cached permissions = %v, want %v", got, want)
+	} else if got = stored(t, store, id).Permissions(); !slices.Equal(got, want) {
+		t.Errorf("stored permissions = %v, want %v", got, want)
+	}
+
+	if removed, err := s.RemovePermission(id, "kit.vip@server=lobby", "console"); err != nil || !removed {
+		t.Fatalf("RemovePermission = %v, %v, want true", removed, err)
+	} else if removed, err = s.RemovePermission(id, "kit.vip@server=lobby", "console"); err != nil || removed {
+		t.Errorf("RemovePermission of a missing permission = %v, %v, want false", removed, err)
+	}
+
+	if got := stored(t, store, id).Permissions(); len(got) != 0 {
+		t.Errorf("stored permissions = %v, want none", got)
+	}
+
+	entries, _, err := auditService.Query(audit.Filter{GroupID: id}, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 3 {
+		t.Errorf("audit entries = %d, want the creation and 2 permission changes", len(entries))
+	}
+}
+
+func TestChangesRequireActor(t *testing.T) {
+	s, _, _ := newTestService(t)
+
+	id, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.Insert("Member", ""); !errors.Is(err, ErrNoActor) {
+		t.Errorf("Insert err = %v, want ErrNoActor", err)
+	}
+	if _, err = s.Update(id, map[string]interface{}{"weight": float64(10)}, ""); !errors.Is(err, ErrNoActor) {
+		t.Errorf("Update err = %v, want ErrNoActor", err)
+	}
+	if _, err = s.AddPermission(id, "kit.vip", ""); !errors.Is(err, ErrNoActor) {
+		t.Errorf("AddPermission err = %v, want ErrNoActor", err)
+	}
+	if _, err = s.RemovePermission(id, "kit.vip", ""); !errors.Is(err, ErrNoActor) {
+		t.Errorf("RemovePermission err = %v, want ErrNoActor", err)
+	}
+	if err = s.Delete(id, ""); !errors.Is(err, ErrNoActor) {
+		t.Errorf("Delete err = %v, want ErrNoActor", err)
+	}
+
+	if g := s.LookupByID(id); g == nil || g.Weight() != 0 {
+		t.Errorf("LookupByID = %v, want the group unchanged", g)
+	}
+}
+
+func TestChangesArePublishedInOrder(t *testing.T) {
+	var subjects []string
+	s, err := NewService(NewMemoryStore(), Dependencies{
+		Audit: audit.NewService(audit.NewMemoryStore()),
+		Publish: func(subject string, _ map[string]interface{}) {
+			subjects = append(subjects, subject)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err = s.Update(id, map[string]interface{}{"weight": float64(10)}, "console"); err != nil {
+		t.Fatal(err)
+	} else if _, err = s.AddPermission(id, "kit.vip", "console"); err != nil {
+		t.Fatal(err)
+	} else if err = s.Delete(id, "console"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every change is announced before it returns.
+	want := []string{SubjectCreateGroup, SubjectUpdateGroup, SubjectPermissionsGroup, SubjectDeleteGroup}
+	if !slices.Equal(subjects, want) {
+		t.Errorf("subjects = %v, want %v", subjects, want)
+	}
+}
+
+func TestUpdateReplacesCachedGroup(t *testing.T) {
+	s, _, _ := newTestService(t)
+
+	id, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := s.LookupByID(id)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// Readers of the cached group race with the updates below.
+		for range 100 {
+			if g := s.LookupByID(id); g != nil {
+				_ = g.Marshal()
+			}
+		}
+	}()
+
+	for i := range 10 {
+		if _, err = s.Update(id, map[string]interface{}{"prefix": strconv.Itoa(i)}, "console"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+
+	if old.Prefix() != "" {
+		t.Errorf("prefix of the previously cached group = %q, want it unchanged", old.Prefix())
+	} else if g := s.LookupByID(id); g == old || g.Prefix() != "9" {
+		t.Errorf("cached group prefix = %q, want the updated copy", g.Prefix())
+	}
+}
+
+func TestPermissionChangesReplaceCachedGroup(t *testing.T) {
+	s, _, _ := newTestService(t)
+
+	id, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := s.LookupByID(id)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// Readers of the cached group race with the permission changes below.
+		for range 100 {
+			if g := s.LookupByID(id); g != nil {
+				_ = g.Marshal()
+			}
+		}
+	}()
+
+	for i := range 10 {
+		permission := "kit.vip" + strconv.Itoa(i)
+		if _, err = s.AddPermission(id, permission, "console"); err != nil {
+			t.Fatal(err)
+		} else if _, err = s.RemovePermission(id, permission, "console"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = s.AddPermission(id, "kit.vip", "console"); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	if got := old.Permissions(); len(got) != 0 {
+		t.Errorf("permissions of the previously cached group = %v, want them unchanged", got)
+	} else if got = s.LookupByID(id).Permissions(); !slices.Equal(got, []string{"kit.vip"}) {
+		t.Errorf("cached permissions = %v, want [kit.vip]", got)
+	}
+}
+
+// insertHookStore is a GroupStore calling onInsert instead of inserting the groups.
+type insertHookStore struct {
+	*MemoryStore
+
+	onInsert func(g *model.Group) error
+}
+
+func (hs insertHookStore) Insert(g *model.Group) error {
+	return hs.onInsert(g)
+}
+
+func TestInsertCachesOnlyPersistedGroups(t *testing.T) {
+	s, store, _ := newTestService(t)
+
+	failure := errors.New("store unavailable")
+	s.store = insertHookStore{MemoryStore: store, onInsert: func(g *model.Group) error {
+		if s.LookupByID(g.ID()) != nil || len(s.Values()) != 0 {
+			t.Error("the group is cached before it is persisted")
+		}
+
+		return failure
+	}}
+
+	if _, err := s.Insert("Admin", "console"); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want the store failure", err)
+	} else if len(s.Values()) != 0 {
+		t.Error("the group is cached although it was not persisted")
+	}
+
+	// The name of the group that failed to be persisted is released.
+	s.store = store
+	if _, err := s.Insert("admin", "console"); err != nil {
+		t.Errorf("Insert after a failure err = %v", err)
+	}
+}
+
+func TestDeleteRemovesGroupFromParents(t *testing.T) {
+	s, store, _ := newTestService(t)
+
+	memberID, err := s.Insert("Member", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vipID, err := s.Insert("VIP", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminID, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err = s.Update(adminID, map[string]interface{}{"parents": []interface{}{memberID, vipID}}, "console"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.Delete(memberID, "console"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{vipID}
+	if got := s.LookupByID(adminID).Parents(); !slices.Equal(got, want) {
+		t.Errorf("cached parents = %v, want %v", got, want)
+	} else if got = stored(t, store, adminID).Parents(); !slices.Equal(got, want) {
+		t.Errorf("stored parents = %v, want %v", got, want)
+	} else if s.LookupByID(memberID) != nil {
+		t.Error("the deleted group is still cached")
+	}
+}
+
+func TestEffectivePermissions(t *testing.T) {
+	s, _, _ := newTestService(t)
+
+	memberID, err := s.Insert("Member", "console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminID, err := s.Insert("Admin", "console")
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err = s.Update(adminID, map[string]interface{}{"parents": []interface{}{memberID}}, "console"); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, permissions := range map[string][]string{
+		memberID: {"kit.vip", "kit.daily", "fly@server=lobby", "chat.*"},
+		adminID:  {"-kit.vip", "kit.daily", "chat.color"},
+	} {
+		for _, permission := range permissions {
+			if _, err = s.AddPermission(id, permission, "console"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	resolutions, err := s.EffectivePermissions(adminID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]Resolution{}
+	for _, r := range resolutions {
+		got[r.GroupID+" "+r.Entry] = *r
+	}
+	// The member entries for kit.vip and kit.daily are overridden by the admin ones.
+	want := map[string]Resolution{
+		adminID + " -kit.vip":          {Allowed: false, GroupID: adminID, Entry: "-kit.vip"},
+		adminID + " kit.daily":         {Allowed: true, GroupID: adminID, Entry: "kit.daily"},
+		adminID + " chat.color":        {Allowed: true, GroupID: adminID, Entry: "chat.color"},
+		memberID + " fly@server=lobby": {Allowed: true, GroupID: memberID, Entry: "fly@server=lobby"},
+		memberID + " chat.*":           {Allowed: true, GroupID: memberID, Entry: "chat.*"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("effective permissions = %v, want %v", got, want)
+	}
+	for key, r := range want {
+		if got[key] != r {
+			t.Errorf("effective permission %q = %+v, want %+v", key, got[key], r)
+		}
+	}
+
+	if _, err = s.EffectivePermissions("missing"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("err = %v, want ErrGroupNotFound", err)
+	}
+}
+
+func TestPermissionsIgnoreCase(t *testing.T) {
+	store := NewMemoryStore()
+	legacy := model.NewGroup("admin", "Admin")
+	legacy.AddPermission("Kit.VIP")
+	if err := store.Insert(legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewService(store, Dependencies{
+		Audit:   audit.NewService(audit.NewMemoryStore()),
+		Publish: func(string, map[string]interface{}) {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if added, err := s.AddPermission("admin", "kit.vip", "console"); err != nil || added {
+		t.Errorf("AddPermission of a permission stored in another case = %v, %v, want false", added, err)
+	} else if removed, err := s.RemovePermission("admin", "KIT.vip", "console"); err != nil || !removed {
+		t.Errorf("RemovePermission of a permission stored in another case = %v, %v, want true", removed, err)
+	} else if got := stored(t, store, "admin").Permissions(); len(got) != 0 {
+		t.Errorf("stored permissions = %v, want none", got)
+	}
+}
@@ -0,0 +1,48 @@
+package bgroups
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/bgroups/model"
+	"github.com/Mides-Projects/Kyro/storage"
+	"github.com/Mides-Projects/Operator/helper"
+)
+
+// GroupStore persists the groups.
+type GroupStore interface {
+	// All returns every stored group.
+	All() ([]*model.Group, error)
+	// Insert inserts a new group.
+	// It returns ErrGroupNameTaken if another group has the same name, ignoring case.
+	Insert(g *model.Group) error
+	// Update sets the given marshaled fields on the group with the given ID.
+	// It returns ErrGroupNameTaken if the group is renamed to the name of another group, ignoring case.
+	Update(id string, fields map[string]interface{}) error
+	// Delete deletes the group with the given ID.
+	Delete(id string) error
+	// AddPermission adds the permission to the group with the given ID, if missing.
+	AddPermission(id, permission string) error
+	// RemovePermission removes the permission from the group with the given ID.
+	RemovePermission(id, permission string) error
+}
+
+// newStore returns the GroupStore of the storage driver selected by the configuration.
+func newStore() (GroupStore, error) {
+	switch driver := storage.Driver(); driver {
+	case storage.DriverMongo:
+		ms := NewMongoStore(helper.MongoClient.Database(helper.MongoDBName).Collection("groups"))
+		if err := ms.EnsureIndexes(); err != nil {
+			return nil, err
+		}
+
+		return ms, nil
+	case storage.DriverSQLite:
+		db, err := storage.SQLite()
+		if err != nil {
+			return nil, err
+		}
+
+		return NewSQLiteStore(db), nil
+	default:
+		return nil, errors.New("unknown storage driver '" + driver + "'")
+	}
+}
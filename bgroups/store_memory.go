@@ -0,0 +1,130 @@
+package bgroups
+
+import (
+	"github.com/Mides-Projects/Kyro/bgroups/model"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MemoryStore is a GroupStore that keeps the groups in memory.
+// The groups are stored marshaled so callers never share them with the store.
+type MemoryStore struct {
+	values map[string]map[string]interface{}
+	mu     sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		values: make(map[string]map[string]interface{}),
+	}
+}
+
+// All returns every stored group.
+func (ms *MemoryStore) All() ([]*model.Group, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	groups := make([]*model.Group, 0, len(ms.values))
+	for _, body := range ms.values {
+		g := &model.Group{}
+		if err := g.Unmarshal(body); err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, g)
+	}
+
+	return groups, nil
+}
+
+// Insert inserts a new group.
+func (ms *MemoryStore) Insert(g *model.Group) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.nameTaken(g.ID(), g.Name()) {
+		return ErrGroupNameTaken
+	}
+
+	ms.values[g.ID()] = g.Marshal()
+
+	return nil
+}
+
+// Update sets the given marshaled fields on the group with the given ID.
+func (ms *MemoryStore) Update(id string, fields map[string]interface{}) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if name, ok := fields["name"].(string); ok && ms.nameTaken(id, name) {
+		return ErrGroupNameTaken
+	}
+
+	ms.apply(id, func(body map[string]interface{}) {
+		maps.Copy(body, fields)
+	})
+
+	return nil
+}
+
+// Delete deletes the group with the given ID.
+func (ms *MemoryStore) Delete(id string) error {
+	ms.mu.Lock()
+	delete(ms.values, id)
+	ms.mu.Unlock()
+
+	return nil
+}
+
+// AddPermission adds the permission to the group with the given ID, if missing.
+func (ms *MemoryStore) AddPermission(id, permission string) error {
+	return ms.modify(id, func(body map[string]interface{}) {
+		if permissions, _ := body["permissions"].([]string); !slices.Contains(permissions, permission) {
+			body["permissions"] = append(slices.Clone(permissions), permission)
+		}
+	})
+}
+
+// RemovePermission removes the permission from the group with the given ID.
+func (ms *MemoryStore) RemovePermission(id, permission string) error {
+	return ms.modify(id, func(body map[string]interface{}) {
+		permissions, _ := body["permissions"].([]string)
+		body["permissions"] = slices.DeleteFunc(slices.Clone(permissions), func(p string) bool {
+			return p == permission
+		})
+	})
+}
+
+// modify applies fn to a copy of the stored body of the group with the given ID.
+func (ms *MemoryStore) modify(id string, fn func(body map[string]interface{})) error {
+	ms.mu.Lock()
+	ms.apply(id, fn)
+	ms.mu.Unlock()
+
+	return nil
+}
+
+// apply applies fn to a copy of the stored body of the group with the given ID,
+// because readers may be unmarshalling the stored one outside the lock.
+// The caller must hold the lock.
+func (ms *MemoryStore) apply(id string, fn func(body map[string]interface{})) {
+	if body, ok := ms.values[id]; ok {
+		body = maps.Clone(body)
+		fn(body)
+		ms.values[id] = body
+	}
+}
+
+// nameTaken returns if a group other than the one with the given ID has the name, ignoring case.
+// The caller must hold the lock.
+func (ms *MemoryStore) nameTaken(id, name string) bool {
+	for otherID, body := range ms.values {
+		if otherName, _ := body["name"].(string); otherID != id && strings.EqualFold(otherName, name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,99 @@
+package bgroups
+
+import (
+	"context"
+	"github.com/Mides-Projects/Kyro/bgroups/model"
+	"github.com/Mides-Projects/Kyro/storage"
+	"github.com/Mides-Projects/Operator/helper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoStore is a GroupStore backed by a MongoDB collection.
+type MongoStore struct {
+	col *mongo.Collection
+	ctx context.Context
+}
+
+func NewMongoStore(col *mongo.Collection) *MongoStore {
+	return &MongoStore{
+		col: col,
+		// caching the context helps a lot with performance and memory usage
+		ctx: context.Background(),
+	}
+}
+
+// indexes are the indexes the groups collection relies on. The name index is unique
+// and case-insensitive, so two instances can never create groups with the same name.
+var indexes = []storage.Index{
+	{Name: "name_unique", Keys: []string{"name"}, Unique: true, CaseInsensitive: true},
+}
+
+// EnsureIndexes creates the indexes the store relies on if they are missing.
+func (ms *MongoStore) EnsureIndexes() error {
+	return storage.EnsureIndexes(ms.ctx, ms.col, indexes)
+}
+
+// All returns every stored group. Groups that cannot be decoded are logged and skipped.
+func (ms *MongoStore) All() ([]*model.Group, error) {
+	cur, err := ms.col.Find(ms.ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ms.ctx)
+
+	var groups []*model.Group
+	for cur.Next(ms.ctx) {
+		var body map[string]interface{}
+		g := &model.Group{}
+
+		if err = cur.Decode(&body); err != nil {
+			helper.Log.Error(helper.ServiceId+": failed to decode group", "error", err)
+		} else if err = g.Unmarshal(body); err != nil {
+			helper.Log.Error(helper.ServiceId+": failed to unmarshal group", "error", err, "body", body)
+		} else {
+			groups = append(groups, g)
+		}
+	}
+
+	return groups, cur.Err()
+}
+
+// Insert inserts a new group.
+func (ms *MongoStore) Insert(g *model.Group) error {
+	if _, err := ms.col.InsertOne(ms.ctx, g.Marshal()); mongo.IsDuplicateKeyError(err) {
+		return ErrGroupNameTaken
+	} else {
+		return err
+	}
+}
+
+// Update sets the given marshaled fields on the group with the given ID.
+func (ms *MongoStore) Update(id string, fields map[string]interface{}) error {
+	if _, err := ms.col.UpdateOne(ms.ctx, bson.M{"_id": id}, bson.M{"$set": fields}); mongo.IsDuplicateKeyError(err) {
+		return ErrGroupNameTaken
+	} else {
+		return err
+	}
+}
+
+// Delete deletes the group with the given ID.
+func (ms *MongoStore) Delete(id string) error {
+	_, err := ms.col.DeleteOne(ms.ctx, bson.M{"_id": id})
+
+	return err
+}
+
+// AddPermission adds the permission to the group with the given ID, if missing.
+func (ms *MongoStore) AddPermission(id, permission string) error {
+	_, err := ms.col.UpdateOne(ms.ctx, bson.M{"_id": id}, bson.M{"$addToSet": bson.M{"permissions": permission}})
+
+	return err
+}
+
+// RemovePermission removes the permission from the group with the given ID.
+func (ms *MongoStore) RemovePermission(id, permission string) error {
+	_, err := ms.col.UpdateOne(ms.ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{"permissions": permission}})
+
+	return err
+}
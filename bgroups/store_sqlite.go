@@ -0,0 +1,135 @@
+package bgroups
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Mides-Projects/Kyro/bgroups/model"
+	"github.com/Mides-Projects/Kyro/storage"
+	"github.com/bytedance/sonic"
+	"maps"
+	"slices"
+)
+
+// SQLiteStore is a GroupStore backed by the groups table of an SQLite database,
+// see storage.SQLite for the schema. Each row keeps the marshaled group as JSON
+// so it has the same shape as a MongoDB document.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{
+		db: db,
+	}
+}
+
+// All returns every stored group.
+func (ss *SQLiteStore) All() ([]*model.Group, error) {
+	rows, err := ss.db.Query(`SELECT body FROM groups`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*model.Group
+	for rows.Next() {
+		var raw string
+		if err = rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+
+		var body map[string]interface{}
+		if err = sonic.UnmarshalString(raw, &body); err != nil {
+			return nil, err
+		}
+
+		g := &model.Group{}
+		if err = g.Unmarshal(body); err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, g)
+	}
+
+	return groups, rows.Err()
+}
+
+// Insert inserts a new group.
+func (ss *SQLiteStore) Insert(g *model.Group) error {
+	raw, err := sonic.MarshalString(g.Marshal())
+	if err != nil {
+		return err
+	}
+
+	if _, err = ss.db.Exec(`INSERT INTO groups (id, name, body) VALUES (?, ?, ?)`, g.ID(), g.Name(), raw); storage.IsUniqueViolation(err) {
+		return ErrGroupNameTaken
+	}
+
+	return err
+}
+
+// Update sets the given marshaled fields on the group with the given ID.
+func (ss *SQLiteStore) Update(id string, fields map[string]interface{}) error {
+	return ss.modify(id, func(body map[string]interface{}) {
+		maps.Copy(body, fields)
+	})
+}
+
+// Delete deletes the group with the given ID.
+func (ss *SQLiteStore) Delete(id string) error {
+	_, err := ss.db.Exec(`DELETE FROM groups WHERE id = ?`, id)
+
+	return err
+}
+
+// AddPermission adds the permission to the group with the given ID, if missing.
+func (ss *SQLiteStore) AddPermission(id, permission string) error {
+	return ss.modify(id, func(body map[string]interface{}) {
+		if permissions, _ := body["permissions"].([]interface{}); !slices.Contains(permissions, interface{}(permission)) {
+			body["permissions"] = append(permissions, permission)
+		}
+	})
+}
+
+// RemovePermission removes the permission from the group with the given ID.
+func (ss *SQLiteStore) RemovePermission(id, permission string) error {
+	return ss.modify(id, func(body map[string]interface{}) {
+		permissions, _ := body["permissions"].([]interface{})
+		body["permissions"] = slices.DeleteFunc(permissions, func(p interface{}) bool {
+			return p == permission
+		})
+	})
+}
+
+// modify applies fn to the stored body of the group with the given ID in a transaction.
+func (ss *SQLiteStore) modify(id string, fn func(body map[string]interface{})) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var raw string
+	if err = tx.QueryRow(`SELECT body FROM groups WHERE id = ?`, id).Scan(&raw); errors.Is(err, sql.ErrNoRows) {
+		return tx.Rollback() // Nothing to modify, like an update matching no document.
+	} else if err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	var body map[string]interface{}
+	if err = sonic.UnmarshalString(raw, &body); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	fn(body)
+
+	name, _ := body["name"].(string)
+	if raw, err = sonic.MarshalString(body); err != nil {
+		return errors.Join(err, tx.Rollback())
+	} else if _, err = tx.Exec(`UPDATE groups SET name = ?, body = ? WHERE id = ?`, name, raw, id); storage.IsUniqueViolation(err) {
+		return errors.Join(ErrGroupNameTaken, tx.Rollback())
+	} else if err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,69 @@
+package grants
+
+import (
+	"github.com/Mides-Projects/Operator/helper"
+	"sync"
+)
+
+const (
+	// MaxBatchLookup is the maximum number of players accepted by HandleBatchLookup.
+	MaxBatchLookup = 500
+	// batchConcurrency is the number of players looked up at the same time by HandleBatchLookup.
+	batchConcurrency = 16
+)
+
+// HandleBatchLookup handles the lookup of several players at once.
+// Every value is resolved with HandleLookup, at most batchConcurrency at a time,
+// and reported on its own so one failing player does not fail the others.
+func (s *ServiceImpl) HandleBatchLookup(values []string, idSrc, exp bool, scope string) map[string]interface{} {
+	var (
+		results = make(map[string]interface{}, len(values))
+		seen    = make(map[string]bool, len(values))
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, batchConcurrency)
+	)
+
+	for _, v := range values {
+		if seen[v] {
+			continue // Duplicated value, it is already being looked up.
+		}
+		seen[v] = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(v string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			var result map[string]interface{}
+			if body, err := s.HandleLookup(v, idSrc, exp, scope); err != nil {
+				result = map[string]interface{}{
+					"status":  "error",
+					"message": helper.ServiceId + ": " + err.Error(),
+				}
+			} else if body == nil {
+				result = map[string]interface{}{
+					"status":  "not_found",
+					"message": "No such player found",
+				}
+			} else {
+				result = map[string]interface{}{
+					"status": "ok",
+					"body":   body,
+				}
+			}
+
+			mu.Lock()
+			results[v] = result
+			mu.Unlock()
+		}(v)
+	}
+
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,78 @@
+package grants
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Mides-Projects/Kyro/bgroups"
+	"github.com/Mides-Projects/Kyro/grants/model"
+)
+
+const (
+	// PolicyReject refuses to delete a group that is still granted to a player.
+	PolicyReject = "reject"
+	// PolicyRevoke revokes every active grant of the group before deleting it.
+	PolicyRevoke = "revoke"
+	// PolicyReassign moves every active grant of the group to another group before deleting it.
+	PolicyReassign = "reassign"
+)
+
+// HandleGroupDelete handles the deletion of a group and the active grants referencing it
+// according to the given policy. The target is the ID of the group used by PolicyReassign
+// and the actor, required by every policy, is who is recorded as the revoker by PolicyRevoke
+// and in the audit log. The group cannot be granted until it is deleted.
+func (s *ServiceImpl) HandleGroupDelete(groupID, policy, target, actor string) (map[string]interface{}, error) {
+	mu := s.groupLock(groupID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if g := s.deps.Groups.LookupByID(groupID); g == nil {
+		return nil, bgroups.ErrGroupNotFound
+	} else if policy != PolicyReject && policy != PolicyRevoke && policy != PolicyReassign {
+		return nil, fmt.Errorf("%w: unknown policy '%s'", ErrInvalidPolicy, policy)
+	} else if actor == "" {
+		return nil, fmt.Errorf("%w: no actor provided", ErrInvalidPolicy)
+	} else if policy == PolicyReassign && target == groupID {
+		return nil, fmt.Errorf("%w: cannot reassign to the deleted group", ErrInvalidPolicy)
+	} else if policy == PolicyReassign && s.deps.Groups.LookupByID(target) == nil {
+		return nil, fmt.Errorf("%w: target group '%s' does not exist", ErrInvalidPolicy, target)
+	}
+
+	actives, err := s.ActivesByGrant(model.KeyGroup, groupID)
+	if err != nil {
+		return nil, err
+	} else if policy == PolicyReject && len(actives) > 0 {
+		return nil, fmt.Errorf("%w: %d active grant(s) reference the group", ErrGroupInUse, len(actives))
+	}
+
+	affected := make([]string, 0, len(actives))
+	for _, gi := range actives {
+		if policy == PolicyRevoke {
+			_, err = s.Revoke(gi.ID(), actor, "Group deleted")
+		} else {
+			_, err = s.Reassign(gi.ID(), target, actor)
+		}
+
+		if errors.Is(err, ErrGrantRevoked) || errors.Is(err, ErrGrantNotFound) {
+			continue // Revoked in the meantime, nothing to do.
+		} else if err != nil {
+			return nil, err
+		}
+
+		affected = append(affected, gi.ID())
+	}
+
+	if err = s.deps.Groups.Delete(groupID, actor); err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"id":     groupID,
+		"policy": policy,
+		"grants": affected,
+	}
+	if policy == PolicyReassign {
+		body["target"] = target
+	}
+
+	return body, nil
+}
@@ -0,0 +1,62 @@
+package grants
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/grants/model"
+	"testing"
+	"time"
+)
+
+// blockingGroups is a GroupService whose deletions block until release is closed.
+type blockingGroups struct {
+	*fakeGroups
+
+	deleting chan struct{}
+	release  chan struct{}
+}
+
+func (bg blockingGroups) Delete(id, actor string) error {
+	close(bg.deleting)
+	<-bg.release
+
+	return bg.fakeGroups.Delete(id, actor)
+}
+
+func TestHandleGroupDeleteBlocksGrants(t *testing.T) {
+	env := newTestEnv()
+	groups := blockingGroups{fakeGroups: env.groups, deleting: make(chan struct{}), release: make(chan struct{})}
+	env.s.deps.Groups = groups
+
+	deleted := make(chan error)
+	go func() {
+		_, err := env.s.HandleGroupDelete("member", PolicyReject, "", "console")
+		deleted <- err
+	}()
+	<-groups.deleting
+
+	// The group is granted after the check of its grants, but before it is deleted.
+	granted := make(chan error)
+	go func() {
+		_, err := env.s.Grant("p1", model.KeyGroup, "member", "console", time.Time{}, nil)
+		granted <- err
+	}()
+
+	select {
+	case err := <-granted:
+		t.Fatalf("Grant returned %v while the group was being deleted", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(groups.release)
+	if err := <-deleted; err != nil {
+		t.Fatal(err)
+	} else if err = <-granted; !errors.Is(err, ErrInvalidGrant) {
+		t.Errorf("Grant err = %v, want ErrInvalidGrant for the deleted group", err)
+	}
+
+	if grants, err := env.store.FindByGrant(model.KeyGroup, "member"); err != nil {
+		t.Fatal(err)
+	} else if len(grants) != 0 {
+		t.Errorf("grants of the deleted group = %d, want none", len(grants))
+	}
+}
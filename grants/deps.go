@@ -0,0 +1,88 @@
+package grants
+
+import (
+	"github.com/Mides-Projects/Kyro/audit"
+	"github.com/Mides-Projects/Kyro/bgroups"
+	bgmodel "github.com/Mides-Projects/Kyro/bgroups/model"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/Mides-Projects/Zurita"
+)
+
+// Player is a player as seen by the service.
+type Player interface {
+	ID() string
+	Name() string
+	Online() bool
+}
+
+// PlayerLookup looks up the players, see zuritaPlayers.
+type PlayerLookup interface {
+	// LookupByID returns the cached player with the given ID, or nil.
+	LookupByID(id string) Player
+	// UnsafeLookupByID returns the player with the given ID, or nil if it does not exist.
+	UnsafeLookupByID(id string) (Player, error)
+	// UnsafeLookupByName returns the player with the given name, or nil if it does not exist.
+	UnsafeLookupByName(name string) (Player, error)
+}
+
+// GroupService is the part of the groups service the grants rely on, see bgroups.ServiceImpl.
+type GroupService interface {
+	LookupByID(id string) *bgmodel.Group
+	ResolvePermission(id, node string, contexts map[string]string) *bgroups.Resolution
+	Delete(id, actor string) error
+}
+
+// Dependencies are the services the grants service relies on besides its store.
+// NewService uses the global service of every field left empty.
+type Dependencies struct {
+	Players PlayerLookup
+	Groups  GroupService
+	Audit   *audit.ServiceImpl
+	// Publish announces a change to the other instances, helper.PublishNats by default.
+	Publish func(subject string, body map[string]interface{})
+}
+
+// withDefaults returns the dependencies with the global services in place of the empty fields.
+func (d Dependencies) withDefaults() Dependencies {
+	if d.Players == nil {
+		d.Players = zuritaPlayers{}
+	}
+	if d.Groups == nil {
+		d.Groups = bgroups.Service()
+	}
+	if d.Audit == nil {
+		d.Audit = audit.Service()
+	}
+	if d.Publish == nil {
+		d.Publish = helper.PublishNats
+	}
+
+	return d
+}
+
+// zuritaPlayers is the PlayerLookup of the Zurita service.
+type zuritaPlayers struct{}
+
+func (zuritaPlayers) LookupByID(id string) Player {
+	if pi := Zurita.Service().LookupByID(id); pi != nil {
+		return pi
+	}
+
+	return nil
+}
+
+func (zuritaPlayers) UnsafeLookupByID(id string) (Player, error) {
+	if pi, err := Zurita.Service().UnsafeLookupByID(id); err != nil || pi == nil {
+		return nil, err
+	} else {
+		return pi, nil
+	}
+}
+
+func (zuritaPlayers) UnsafeLookupByName(name string) (Player, error) {
+	if pi, err := Zurita.Service().UnsafeLookupByName(name); err != nil || pi == nil {
+		return nil, err
+	} else {
+		return pi, nil
+	}
+}
@@ -0,0 +1,112 @@
+package grants
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Mides-Projects/Operator/helper"
+	"sync"
+)
+
+const (
+	// StateActive selects the grants that are neither revoked nor expired.
+	StateActive = "active"
+	// StateExpired selects the grants that are revoked or expired.
+	StateExpired = "expired"
+	// StateAll selects every grant.
+	StateAll = "all"
+)
+
+const (
+	// DefaultHoldersLimit is the number of holders of a page when no limit is requested.
+	DefaultHoldersLimit = 50
+	// MaxHoldersLimit is the maximum number of holders of a page.
+	MaxHoldersLimit = 200
+)
+
+// HandleHolders handles the lookup of the players holding the grants with the given key
+// and value in the given state, one entry per grant ordered by grant ID. The cursor is the
+// one returned for the previous page, or empty for the first page.
+// The names of the players are resolved at most batchConcurrency at a time and are empty
+// if the player is unknown.
+func (s *ServiceImpl) HandleHolders(key, value, state, cursor string, limit int) (map[string]interface{}, error) {
+	if s.store == nil {
+		return nil, errors.New("no grant store")
+	} else if key == "" || value == "" {
+		return nil, fmt.Errorf("%w: no key or value provided", ErrInvalidQuery)
+	} else if state != StateActive && state != StateExpired && state != StateAll {
+		return nil, fmt.Errorf("%w: unknown state '%s'", ErrInvalidQuery, state)
+	} else if limit <= 0 || limit > MaxHoldersLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxHoldersLimit)
+	}
+
+	// One more grant than requested tells if there is a next page.
+	grants, err := s.store.FindPage(GrantQuery{
+		Key:   key,
+		Value: value,
+		State: state,
+		After: cursor,
+		Limit: limit + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	next := ""
+	if len(grants) > limit {
+		grants = grants[:limit]
+		next = grants[limit-1].ID()
+	}
+
+	var (
+		names = make(map[string]string, len(grants))
+		seen  = make(map[string]bool, len(grants))
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, batchConcurrency)
+	)
+	for _, gi := range grants {
+		if seen[gi.SourceID()] {
+			continue // The player holds several of the grants.
+		}
+		seen[gi.SourceID()] = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(id string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			pi, err := s.deps.Players.UnsafeLookupByID(id)
+			if err != nil {
+				helper.Log.Error(helper.ServiceId+": failed to resolve holder name", "player_id", id, "error", err)
+
+				return
+			} else if pi == nil {
+				return
+			}
+
+			mu.Lock()
+			names[id] = pi.Name()
+			mu.Unlock()
+		}(gi.SourceID())
+	}
+
+	wg.Wait()
+
+	holders := make([]map[string]interface{}, 0, len(grants))
+	for _, gi := range grants {
+		holders = append(holders, map[string]interface{}{
+			"player_id": gi.SourceID(),
+			"name":      names[gi.SourceID()],
+			"grant":     gi.Marshal(),
+		})
+	}
+
+	return map[string]interface{}{
+		"holders": holders,
+		"next":    next,
+	}, nil
+}
@@ -2,11 +2,21 @@ package model
 
 import "errors"
 
+// KeyGroup is the grant key used when the value is the ID of a group.
+const KeyGroup = "group"
+
 type Grant struct {
 	key   string
 	value string
 }
 
+func NewGrant(key, value string) Grant {
+	return Grant{
+		key:   key,
+		value: value,
+	}
+}
+
 // Key returns the key of the grant.
 func (g *Grant) Key() string {
 	return g.key
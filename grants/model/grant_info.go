@@ -2,11 +2,18 @@ package model
 
 import (
 	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"path"
+	"strings"
 	"time"
 )
 
+// GlobalScope is the scope of the grants that apply on every server.
+const GlobalScope = "global"
+
 type GrantInfo struct {
-	id string
+	id       string
+	sourceID string // SourceID is the ID of the player who owns the grant.
 
 	grant Grant
 
@@ -15,17 +22,39 @@ type GrantInfo struct {
 
 	expiresAt time.Time
 
-	revokedBy string
-	revokedAt time.Time
+	revokedBy    string
+	revokedAt    time.Time
+	revokeReason string
 
 	scopes []string
 }
 
+func NewGrantInfo(id, sourceID string, grant Grant, addedBy string, addedAt, expiresAt time.Time, scopes []string) *GrantInfo {
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return &GrantInfo{
+		id:        id,
+		sourceID:  sourceID,
+		grant:     grant,
+		addedBy:   addedBy,
+		addedAt:   addedAt,
+		expiresAt: expiresAt,
+		scopes:    scopes,
+	}
+}
+
 // ID returns the ID of the grant.
 func (gi *GrantInfo) ID() string {
 	return gi.id
 }
 
+// SourceID returns the ID of the player who owns the grant.
+func (gi *GrantInfo) SourceID() string {
+	return gi.sourceID
+}
+
 // Grant returns the grant of the grant.
 func (gi *GrantInfo) Grant() Grant {
 	return gi.grant
@@ -46,6 +75,11 @@ func (gi *GrantInfo) ExpiresAt() time.Time {
 	return gi.expiresAt
 }
 
+// SetExpiresAt sets the expires at of the grant, the zero time if it never expires.
+func (gi *GrantInfo) SetExpiresAt(expiresAt time.Time) {
+	gi.expiresAt = expiresAt
+}
+
 // RevokedBy returns the revoked by of the grant.
 func (gi *GrantInfo) RevokedBy() string {
 	return gi.revokedBy
@@ -66,13 +100,23 @@ func (gi *GrantInfo) SetRevokedAt(revokedAt time.Time) {
 	gi.revokedAt = revokedAt
 }
 
+// RevokeReason returns the reason why the grant was revoked.
+func (gi *GrantInfo) RevokeReason() string {
+	return gi.revokeReason
+}
+
+// SetRevokeReason sets the reason why the grant was revoked.
+func (gi *GrantInfo) SetRevokeReason(revokeReason string) {
+	gi.revokeReason = revokeReason
+}
+
 // Expired returns if the grant is expired.
 func (gi *GrantInfo) Expired() bool {
-	if gi.revokedAt.Unix() != 0 {
+	if unix(gi.revokedAt) != 0 {
 		return true
 	}
 
-	return gi.expiresAt.Unix() > 0 && time.Now().After(gi.expiresAt)
+	return unix(gi.expiresAt) > 0 && time.Now().After(gi.expiresAt)
 }
 
 // Scopes returns the scopes of the grant.
@@ -85,22 +129,45 @@ func (gi *GrantInfo) SetScopes(scopes []string) {
 	gi.scopes = scopes
 }
 
+// AppliesTo returns if the grant applies to the given scope.
+// A grant without scopes or with the GlobalScope applies everywhere, and grant scopes
+// may use wildcards such as "bedwars-*". An empty scope matches every grant.
+func (gi *GrantInfo) AppliesTo(scope string) bool {
+	if scope == "" || len(gi.scopes) == 0 {
+		return true
+	}
+
+	scope = strings.ToLower(scope)
+	for _, s := range gi.scopes {
+		s = strings.ToLower(s)
+		if s == GlobalScope || s == scope {
+			return true
+		} else if ok, err := path.Match(s, scope); err == nil && ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Marshal returns the grant info as a map.
 func (gi *GrantInfo) Marshal() map[string]interface{} {
 	body := map[string]interface{}{
-		"_id":   gi.id,
-		"grant": gi.grant.Marshal(),
+		"_id":       gi.id,
+		"source_id": gi.sourceID,
+		"grant":     gi.grant.Marshal(),
 
 		"added_by": gi.addedBy,
-		"added_at": gi.addedAt.Unix(),
+		"added_at": unix(gi.addedAt),
 
-		"expires_at": gi.expiresAt.Unix(),
+		"expires_at": unix(gi.expiresAt),
 		"scopes":     gi.scopes,
 	}
 
-	if gi.revokedBy != "" && gi.revokedAt.Unix() != 0 {
+	if gi.revokedBy != "" && unix(gi.revokedAt) != 0 {
 		body["revoked_by"] = gi.revokedBy
-		body["revoked_at"] = gi.revokedAt.Unix()
+		body["revoked_at"] = unix(gi.revokedAt)
+		body["revoke_reason"] = gi.revokeReason
 	}
 
 	return body
@@ -114,8 +181,22 @@ func (gi *GrantInfo) Unmarshal(body map[string]interface{}) error {
 	}
 	gi.id = id
 
+	if sourceID, ok := body["source_id"].(string); ok {
+		gi.sourceID = sourceID
+	}
+
+	var grantBody map[string]interface{}
+	switch v := body["grant"].(type) {
+	case map[string]interface{}:
+		grantBody = v
+	case primitive.M:
+		grantBody = v
+	default:
+		return errors.New("grant is not an object")
+	}
+
 	grant := &Grant{}
-	if err := grant.Unmarshal(body["grant"].(map[string]interface{})); err != nil {
+	if err := grant.Unmarshal(grantBody); err != nil {
 		return err
 	}
 	gi.grant = *grant // reassign the pointer to the value
@@ -126,13 +207,13 @@ func (gi *GrantInfo) Unmarshal(body map[string]interface{}) error {
 	}
 	gi.addedBy = addedBy
 
-	addedAt, ok := body["added_at"].(int64)
+	addedAt, ok := toInt64(body["added_at"])
 	if !ok {
 		return errors.New("added_at is not an integer")
 	}
 	gi.addedAt = time.Unix(addedAt, 0)
 
-	expiresAt, ok := body["expires_at"].(int64)
+	expiresAt, ok := toInt64(body["expires_at"])
 	if !ok {
 		return errors.New("expires_at is not an integer")
 	}
@@ -142,21 +223,72 @@ func (gi *GrantInfo) Unmarshal(body map[string]interface{}) error {
 		gi.revokedBy = revokedBy
 	}
 
-	if revokedAt, ok := body["revoked_at"].(int64); ok {
+	if revokedAt, ok := toInt64(body["revoked_at"]); ok {
 		gi.revokedAt = time.Unix(revokedAt, 0)
 	}
 
-	if scopes, ok := body["scopes"].([]interface{}); !ok {
-		return errors.New("scopes is not an array")
-	} else {
-		for _, scope := range scopes {
-			if s, ok := scope.(string); !ok {
-				return errors.New("scope is not a string")
-			} else {
-				gi.scopes = append(gi.scopes, s)
-			}
-		}
+	if revokeReason, ok := body["revoke_reason"].(string); ok {
+		gi.revokeReason = revokeReason
 	}
 
+	scopes, err := toStrings(body["scopes"])
+	if err != nil {
+		return errors.Join(errors.New("scopes is not a valid array"), err)
+	}
+	gi.scopes = scopes
+
 	return nil
 }
+
+// unix returns the unix time of t, or 0 if t is the zero time.
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
+}
+
+// toInt64 converts the numeric types produced by the BSON and JSON decoders to int64.
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case int32:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// toStrings converts the array types produced by the BSON and JSON decoders to a string slice.
+func toStrings(v interface{}) ([]string, error) {
+	var values []interface{}
+	switch a := v.(type) {
+	case nil:
+		return []string{}, nil
+	case []string:
+		return a, nil
+	case primitive.A:
+		values = a
+	case []interface{}:
+		values = a
+	default:
+		return nil, errors.New("value is not an array")
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); !ok {
+			return nil, errors.New("value is not a string")
+		} else {
+			result = append(result, s)
+		}
+	}
+
+	return result, nil
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"slices"
 	"sync"
+	"time"
 )
 
 type Tracker struct {
@@ -34,6 +35,20 @@ func (t *Tracker) Actives() []*GrantInfo {
 	return t.actives
 }
 
+// LookupActive returns the active grant with the given ID.
+func (t *Tracker) LookupActive(id string) *GrantInfo {
+	t.activesMu.RLock()
+	defer t.activesMu.RUnlock()
+
+	for _, gi := range t.actives {
+		if gi.ID() == id {
+			return gi
+		}
+	}
+
+	return nil
+}
+
 // Expired returns the expired grants of the player.
 func (t *Tracker) Expired() []GrantInfo {
 	t.expiredMu.RLock()
@@ -42,6 +57,20 @@ func (t *Tracker) Expired() []GrantInfo {
 	return t.expired
 }
 
+// LookupExpired returns a copy of the expired grant with the given ID.
+func (t *Tracker) LookupExpired(id string) *GrantInfo {
+	t.expiredMu.RLock()
+	defer t.expiredMu.RUnlock()
+
+	for _, gi := range t.expired {
+		if gi.ID() == id {
+			return &gi
+		}
+	}
+
+	return nil
+}
+
 // AddActive adds a grant to the active grants of the player.
 func (t *Tracker) AddActive(gi *GrantInfo) {
 	t.activesMu.Lock()
@@ -57,11 +86,54 @@ func (t *Tracker) AddExpired(gi GrantInfo) {
 }
 
 // RemoveActive removes a grant from the active grants of the player.
+// The grants are copied rather than shifted in place because callers of Actives
+// may still be reading the previous slice.
 func (t *Tracker) RemoveActive(gi *GrantInfo) {
 	t.activesMu.Lock()
 	defer t.activesMu.Unlock()
 
 	if idx := slices.Index(t.actives, gi); idx != -1 {
-		t.actives = append(t.actives[:idx], t.actives[idx+1:]...)
+		t.actives = slices.Delete(slices.Clone(t.actives), idx, idx+1)
 	}
 }
+
+// SweepExpired moves the active grants that are expired to the expired grants
+// and returns the grants that were moved.
+func (t *Tracker) SweepExpired() []*GrantInfo {
+	t.activesMu.Lock()
+
+	var swept []*GrantInfo
+	actives := make([]*GrantInfo, 0, len(t.actives))
+	for _, gi := range t.actives {
+		if gi.Expired() {
+			swept = append(swept, gi)
+		} else {
+			actives = append(actives, gi)
+		}
+	}
+	t.actives = actives
+
+	t.activesMu.Unlock()
+
+	for _, gi := range swept {
+		t.AddExpired(*gi)
+	}
+
+	return swept
+}
+
+// NextExpiry returns the nearest expiration time of the active grants,
+// or the zero time if none of them expires.
+func (t *Tracker) NextExpiry() time.Time {
+	t.activesMu.RLock()
+	defer t.activesMu.RUnlock()
+
+	var next time.Time
+	for _, gi := range t.actives {
+		if at := gi.ExpiresAt(); unix(at) > 0 && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,95 @@
+package grants
+
+import (
+	"errors"
+	bgmodel "github.com/Mides-Projects/Kyro/bgroups/model"
+	"github.com/Mides-Projects/Kyro/grants/model"
+	"sort"
+)
+
+// groupGrant is an active grant of a group together with the group it grants.
+type groupGrant struct {
+	gi *model.GrantInfo
+	g  *bgmodel.Group
+}
+
+// groupGrants returns the grants of existing groups among the given grants,
+// ordered from the highest to the lowest weight and then by name.
+func (s *ServiceImpl) groupGrants(grants []*model.GrantInfo) []groupGrant {
+	var result []groupGrant
+	for _, gi := range grants {
+		grant := gi.Grant()
+		if grant.Key() != model.KeyGroup {
+			continue
+		}
+
+		if g := s.deps.Groups.LookupByID(grant.Value()); g != nil {
+			result = append(result, groupGrant{gi: gi, g: g})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].g.Weight() != result[j].g.Weight() {
+			return result[i].g.Weight() > result[j].g.Weight()
+		}
+
+		return result[i].g.Name() < result[j].g.Name()
+	})
+
+	return result
+}
+
+// HandlePermissionCheck handles the check of a permission node for the player with the given ID
+// in the given contexts, such as the server or the gamemode asking. Only the active grants applying
+// to the given scope count, see model.GrantInfo.AppliesTo. Their groups are checked from the highest
+// to the lowest weight and the first one defining the node decides the answer.
+func (s *ServiceImpl) HandlePermissionCheck(id, node, scope string, contexts map[string]string) (map[string]interface{}, error) {
+	if node == "" {
+		return nil, errors.New("no node provided")
+	}
+
+	pi, err := s.deps.Players.UnsafeLookupByID(id)
+	if err != nil {
+		return nil, err
+	} else if pi == nil {
+		return nil, nil
+	}
+
+	t, err := s.UnsafeLookup(pi.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"player_id":       pi.ID(),
+		"node":            node,
+		"scope":           scope,
+		"contexts":        contexts,
+		"allowed":         false,
+		"grant_id":        nil,
+		"group_id":        nil,
+		"source_group_id": nil,
+		"entry":           nil,
+	}
+
+	var scoped []*model.GrantInfo
+	for _, gi := range t.Actives() {
+		if gi.AppliesTo(scope) {
+			scoped = append(scoped, gi)
+		}
+	}
+
+	for _, gg := range s.groupGrants(scoped) {
+		if r := s.deps.Groups.ResolvePermission(gg.g.ID(), node, contexts); r != nil {
+			body["allowed"] = r.Allowed
+			body["grant_id"] = gg.gi.ID()
+			body["group_id"] = gg.g.ID()
+			body["source_group_id"] = r.GroupID
+			body["entry"] = r.Entry
+
+			break
+		}
+	}
+
+	return body, nil
+}
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"github.com/Mides-Projects/Kyro/grants"
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v3"
+	"strconv"
+)
+
+// BatchLookup handles the lookup of the grants of several players at once.
+func BatchLookup(ctx fiber.Ctx) error {
+	var body map[string]interface{}
+	if err := sonic.Unmarshal(ctx.Body(), &body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid body provided",
+		})
+	}
+
+	raw, ok := body["values"].([]interface{})
+	if !ok || len(raw) == 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No values provided",
+		})
+	} else if len(raw) > grants.MaxBatchLookup {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Too many values provided, the maximum is " + strconv.Itoa(grants.MaxBatchLookup),
+		})
+	}
+
+	values := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if value, ok := v.(string); !ok || value == "" {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid value provided",
+			})
+		} else {
+			values = append(values, value)
+		}
+	}
+
+	scope, _ := body["scope"].(string)
+	if exp, ok := body["expired"].(bool); !ok {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No expired provided",
+		})
+	} else if src, ok := body["src"].(string); !ok || src == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No source provided",
+		})
+	} else if src != "id" && src != "gt" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid source provided",
+		})
+	} else {
+		return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+			"results": grants.Service().HandleBatchLookup(values, src == "id", exp, scope),
+		})
+	}
+}
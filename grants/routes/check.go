@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"github.com/Mides-Projects/Kyro/grants"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/gofiber/fiber/v3"
+	"strings"
+)
+
+// Check handles the check of a permission node for a player.
+// Every query parameter besides the node and the scope is a context of the caller, e.g. server=lobby.
+// Only the grants applying to the scope count, which defaults to the server context.
+func Check(ctx fiber.Ctx) error {
+	contexts := map[string]string{}
+	for k, v := range ctx.Queries() {
+		if k != "node" && k != "scope" && v != "" {
+			contexts[strings.ToLower(k)] = v
+		}
+	}
+
+	scope := ctx.Query("scope")
+	if scope == "" {
+		scope = contexts["server"]
+	}
+
+	if id := ctx.Params("id"); id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No player provided",
+		})
+	} else if node := ctx.Query("node"); node == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No node provided",
+		})
+	} else if body, err := grants.Service().HandlePermissionCheck(id, node, scope, contexts); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": helper.ServiceId + ": " + err.Error(),
+		})
+	} else if body == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No such player found",
+		})
+	} else {
+		return ctx.Status(fiber.StatusOK).JSON(body)
+	}
+}
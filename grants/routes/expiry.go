@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/grants"
+	"github.com/Mides-Projects/Kyro/grants/model"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v3"
+	"time"
+)
+
+// Expiry handles the change of when a grant expires. The body either sets the
+// expires at unix time (0 makes the grant permanent) or extends the grant by
+// the given number of seconds, which shortens it if negative.
+// The actor query is who is recorded in the audit log.
+func Expiry(ctx fiber.Ctx) error {
+	var body map[string]interface{}
+	if err := sonic.Unmarshal(ctx.Body(), &body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid body provided",
+		})
+	}
+
+	id := ctx.Params("id")
+	actor := ctx.Query("actor")
+	expiresAt, setOk := body["expires_at"].(float64)
+	extendBy, extendOk := body["extend_by"].(float64)
+
+	var (
+		gi  *model.GrantInfo
+		err error
+	)
+	if id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No grant provided",
+		})
+	} else if actor == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No actor provided",
+		})
+	} else if setOk == extendOk {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Either expires at or extend by must be provided",
+		})
+	} else if setOk && expiresAt < 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid expires at provided",
+		})
+	} else if setOk && expiresAt == 0 {
+		gi, err = grants.Service().SetExpiry(id, time.Time{}, actor)
+	} else if setOk {
+		gi, err = grants.Service().SetExpiry(id, time.Unix(int64(expiresAt), 0), actor)
+	} else if extendBy == 0 {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid extend by provided",
+		})
+	} else {
+		gi, err = grants.Service().ExtendExpiry(id, time.Duration(extendBy)*time.Second, actor)
+	}
+
+	if errors.Is(err, grants.ErrGrantNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No such grant found",
+		})
+	} else if errors.Is(err, grants.ErrGrantRevoked) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": "Grant already revoked",
+		})
+	} else if errors.Is(err, grants.ErrInvalidGrant) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": helper.ServiceId + ": " + err.Error(),
+		})
+	} else {
+		return ctx.Status(fiber.StatusOK).JSON(gi.Marshal())
+	}
+}
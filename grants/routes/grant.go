@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/grants"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v3"
+	"time"
+)
+
+// Grant handles the issue of a new grant to a player.
+// The expires at unix time is optional, 0 or none making the grant permanent.
+// The actor query is who is recorded as having added the grant and in the audit log.
+func Grant(ctx fiber.Ctx) error {
+	var body map[string]interface{}
+	if err := sonic.Unmarshal(ctx.Body(), &body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid body provided",
+		})
+	}
+
+	var scopes []string
+	if raw, ok := body["scopes"].([]interface{}); ok {
+		for _, v := range raw {
+			if scope, ok := v.(string); !ok {
+				return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message": "Invalid scope provided",
+				})
+			} else {
+				scopes = append(scopes, scope)
+			}
+		}
+	} else if body["scopes"] != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid scopes provided",
+		})
+	}
+
+	var expiresAt time.Time
+	if raw, ok := body["expires_at"].(float64); ok && raw > 0 {
+		expiresAt = time.Unix(int64(raw), 0)
+	} else if (ok && raw < 0) || (!ok && body["expires_at"] != nil) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid expires at provided",
+		})
+	}
+
+	if id := ctx.Params("id"); id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No player provided",
+		})
+	} else if key, ok := body["key"].(string); !ok || key == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No key provided",
+		})
+	} else if value, ok := body["value"].(string); !ok || value == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No value provided",
+		})
+	} else if addedBy := ctx.Query("actor"); addedBy == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No actor provided",
+		})
+	} else if gi, err := grants.Service().HandleGrant(id, key, value, addedBy, expiresAt, scopes); errors.Is(err, grants.ErrInvalidGrant) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": helper.ServiceId + ": " + err.Error(),
+		})
+	} else if gi == nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No such player found",
+		})
+	} else {
+		return ctx.Status(fiber.StatusOK).JSON(gi.Marshal())
+	}
+}
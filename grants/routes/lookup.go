@@ -7,6 +7,7 @@ import (
 )
 
 // Lookup handles the lookup of player grants.
+// The optional scope query limits the grants to the ones applying to that scope.
 func Lookup(ctx fiber.Ctx) error {
 	if exp := ctx.Query("expired"); exp == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -28,7 +29,7 @@ func Lookup(ctx fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"message": "No value provided",
 		})
-	} else if body, err := grants.Service().HandleLookup(v, src == "id", exp == "true"); err != nil {
+	} else if body, err := grants.Service().HandleLookup(v, src == "id", exp == "true", ctx.Query("scope")); err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": helper.ServiceId + ": " + err.Error(),
 		})
@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/grants"
+	"github.com/Mides-Projects/Operator/helper"
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Revoke handles the revocation of a grant.
+// The actor query is who is recorded as having revoked the grant and in the audit log.
+func Revoke(ctx fiber.Ctx) error {
+	var body map[string]interface{}
+	if err := sonic.Unmarshal(ctx.Body(), &body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid body provided",
+		})
+	}
+
+	if id := ctx.Params("id"); id == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No grant provided",
+		})
+	} else if revokedBy := ctx.Query("actor"); revokedBy == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No actor provided",
+		})
+	} else if reason, ok := body["reason"].(string); !ok || reason == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "No reason provided",
+		})
+	} else if gi, err := grants.Service().Revoke(id, revokedBy, reason); errors.Is(err, grants.ErrGrantNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No such grant found",
+		})
+	} else if errors.Is(err, grants.ErrGrantRevoked) {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": "Grant already revoked",
+		})
+	} else if errors.Is(err, grants.ErrInvalidGrant) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": helper.ServiceId + ": " + err.Error(),
+		})
+	} else {
+		return ctx.Status(fiber.StatusOK).JSON(gi.Marshal())
+	}
+}
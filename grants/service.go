@@ -1,15 +1,18 @@
 package grants
 
 import (
-	"context"
 	"errors"
+	"fmt"
+	"github.com/Mides-Projects/Kyro/audit"
+	bgmodel "github.com/Mides-Projects/Kyro/bgroups/model"
 	"github.com/Mides-Projects/Kyro/grants/model"
 	"github.com/Mides-Projects/Operator/helper"
 	"github.com/Mides-Projects/Quark"
 	"github.com/Mides-Projects/Zurita"
-	pimodel "github.com/Mides-Projects/Zurita/model"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
+	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"strings"
 	"sync"
 	"time"
 )
@@ -19,22 +22,60 @@ type ServiceImpl struct {
 	mu       sync.RWMutex
 
 	ttlSet *Quark.Set
-	// Player collection from MongoDB.
-	col *mongo.Collection
-	ctx context.Context
+
+	// expiryTimer fires the sweeper at expiryAt, the nearest expiration
+	// time of the active grants across the cached trackers.
+	expiryTimer *time.Timer
+	expiryAt    time.Time
+	expiryMu    sync.Mutex
+
+	// loads are the in-flight loads of the trackers not cached yet,
+	// shared by the concurrent lookups of the same player.
+	loads   map[string]*load
+	loadsMu sync.Mutex
+
+	// groupLocks are read-locked while a grant of the group is issued and locked
+	// while the group is deleted, so no grant issued by this instance references
+	// a deleted group.
+	groupLocks   map[string]*sync.RWMutex
+	groupLocksMu sync.Mutex
+
+	// store persists the grants, see GrantStore.
+	store GrantStore
+	// deps are the other services the service relies on, see Dependencies.
+	deps Dependencies
+}
+
+// load is an in-flight load of a tracker from the MongoDB collection.
+type load struct {
+	done chan struct{} // done is closed once the load finished.
+	// stale is set when a grant of the player changed during the query, which
+	// may have missed the change, see patchCached.
+	stale bool
+
+	t   *model.Tracker
+	err error
 }
 
-// cache caches the tracker information.
-func (s *ServiceImpl) cache(t *model.Tracker, keep bool) {
+// cache caches the tracker information unless a tracker with the same ID
+// is already cached, and returns the cached tracker.
+func (s *ServiceImpl) cache(t *model.Tracker, keep bool) *model.Tracker {
 	s.mu.Lock()
+	if cached, ok := s.trackers[t.ID()]; ok {
+		s.mu.Unlock()
+
+		return cached
+	}
 	s.trackers[t.ID()] = t
 	s.mu.Unlock()
 
-	if keep {
-		return
+	s.scheduleExpiry(t.NextExpiry())
+
+	if !keep {
+		s.ttlSet.Set(t.ID())
 	}
 
-	s.ttlSet.Set(t.ID())
+	return t
 }
 
 // Lookup returns the tracker with the given ID.
@@ -47,35 +88,69 @@ func (s *ServiceImpl) Lookup(id string) *model.Tracker {
 }
 
 // UnsafeLookup returns the tracker with the given ID
-// first by checking the cache and then the MongoDB collection.
-// This method is not thread-safe.
+// first by checking the cache and then the store.
+// Concurrent calls for the same uncached ID share a single query and tracker,
+// which is cached before the load is forgotten so later calls find it in the cache.
+// The tracker of an online player is kept until they quit, see cache.
 func (s *ServiceImpl) UnsafeLookup(id string) (*model.Tracker, error) {
 	if t := s.Lookup(id); t != nil {
 		return t, nil
-	} else if s.col == nil {
-		return nil, errors.New("no MongoDB collection")
-	} else if s.ctx == nil {
-		return nil, errors.New("no context")
 	}
 
-	// Fetch the grants from the MongoDB collection.
-	cur, err := s.col.Find(s.ctx, bson.M{"source_id": id})
+	s.loadsMu.Lock()
+	if l, ok := s.loads[id]; ok {
+		s.loadsMu.Unlock()
+		<-l.done
+
+		return l.t, l.err
+	} else if t := s.Lookup(id); t != nil {
+		s.loadsMu.Unlock()
+
+		return t, nil // Cached by a load that finished meanwhile.
+	}
+
+	l := &load{done: make(chan struct{})}
+	s.loads[id] = l
+	s.loadsMu.Unlock()
+
+	t, err := s.load(id)
+
+	s.loadsMu.Lock()
+	for err == nil && l.stale {
+		l.stale = false
+		s.loadsMu.Unlock()
+
+		t, err = s.load(id)
+
+		s.loadsMu.Lock()
+	}
+
+	if err == nil {
+		pi := s.deps.Players.LookupByID(id)
+		t = s.cache(t, pi != nil && pi.Online())
+	}
+	l.t, l.err = t, err
+	delete(s.loads, id)
+	s.loadsMu.Unlock()
+
+	close(l.done)
+
+	return l.t, l.err
+}
+
+// load loads the tracker with the given ID from the store.
+func (s *ServiceImpl) load(id string) (*model.Tracker, error) {
+	if s.store == nil {
+		return nil, errors.New("no grant store")
+	}
+
+	grants, err := s.store.FindByPlayer(id)
 	if err != nil {
 		return nil, err
 	}
 
 	t := model.NewTracker(id)
-	for cur.Next(s.ctx) {
-		var body map[string]interface{}
-		if err = cur.Decode(&body); err != nil {
-			return nil, err
-		}
-
-		gi := &model.GrantInfo{}
-		if err = gi.Unmarshal(body); err != nil {
-			return nil, err
-		}
-
+	for _, gi := range grants {
 		if gi.Expired() {
 			t.AddExpired(*gi)
 		} else {
@@ -87,15 +162,17 @@ func (s *ServiceImpl) UnsafeLookup(id string) (*model.Tracker, error) {
 }
 
 // HandleLookup handles the lookup of a player.
-func (s *ServiceImpl) HandleLookup(id string, idSrc, exp bool) (map[string]interface{}, error) {
+// Only the grants applying to the given scope are returned, see model.GrantInfo.AppliesTo,
+// while the lookup announced to the other instances has every grant.
+func (s *ServiceImpl) HandleLookup(id string, idSrc, exp bool, scope string) (map[string]interface{}, error) {
 	var (
-		pi  *pimodel.PlayerInfo
+		pi  Player
 		err error
 	)
 	if idSrc {
-		pi, err = Zurita.Service().UnsafeLookupByID(id)
+		pi, err = s.deps.Players.UnsafeLookupByID(id)
 	} else {
-		pi, err = Zurita.Service().UnsafeLookupByName(id)
+		pi, err = s.deps.Players.UnsafeLookupByName(id)
 	}
 
 	if err != nil {
@@ -109,55 +186,484 @@ func (s *ServiceImpl) HandleLookup(id string, idSrc, exp bool) (map[string]inter
 		return nil, err
 	}
 
-	if t == nil {
-		t = model.NewTracker(pi.ID())
-	}
+	body := s.lookupBody(t, exp, scope)
 
-	// Cache the tracker if it does not exist.
-	if s.Lookup(pi.ID()) == nil {
-		s.cache(t, pi.Online())
+	// The other instances get every grant of the player, so they never mistake
+	// the grants of a scope for all of them.
+	published := body
+	if scope != "" {
+		published = s.lookupBody(t, exp, "")
 	}
 
+	go s.deps.Publish(
+		SubjectLookup,
+		map[string]interface{}{
+			"service_id": helper.ServiceId,
+			"player_id":  pi.ID(),
+			"body":       published,
+		},
+	)
+
+	return body, nil
+}
+
+// lookupBody returns the body of the lookup of the tracker, including the expired grants
+// if exp is true. Only the grants applying to the given scope are included.
+func (s *ServiceImpl) lookupBody(t *model.Tracker, exp bool, scope string) map[string]interface{} {
 	expired := make(map[string]interface{})
 	if exp {
 		for _, gi := range t.Expired() {
-			expired[gi.ID()] = gi.Marshal()
+			if gi.AppliesTo(scope) {
+				expired[gi.ID()] = gi.Marshal()
+			}
 		}
 	}
 
+	var scoped []*model.GrantInfo
 	actives := make(map[string]interface{})
 	for _, gi := range t.Actives() {
-		actives[gi.ID()] = gi.Marshal()
+		if gi.AppliesTo(scope) {
+			scoped = append(scoped, gi)
+			actives[gi.ID()] = gi.Marshal()
+		}
 	}
 
 	body := map[string]interface{}{
-		"expired": expired,
-		"actives": actives,
+		"expired":       expired,
+		"actives":       actives,
+		"primary_group": nil,
 	}
 
-	go helper.PublishNats(
-		SubjectLookup,
+	if g := s.PrimaryGroup(scoped); g != nil {
+		body["primary_group"] = g.Marshal()
+	}
+
+	return body
+}
+
+// PrimaryGroup returns the group with the highest weight among the given grants,
+// or nil if none of them is a grant of an existing group.
+// Groups with the same weight are ordered by name to keep the result stable.
+func (s *ServiceImpl) PrimaryGroup(grants []*model.GrantInfo) *bgmodel.Group {
+	if ggs := s.groupGrants(grants); len(ggs) > 0 {
+		return ggs[0].g
+	}
+
+	return nil
+}
+
+// groupLock returns the lock of the group with the given ID, see ServiceImpl.groupLocks.
+func (s *ServiceImpl) groupLock(id string) *sync.RWMutex {
+	s.groupLocksMu.Lock()
+	defer s.groupLocksMu.Unlock()
+
+	mu, ok := s.groupLocks[id]
+	if !ok {
+		mu = &sync.RWMutex{}
+		s.groupLocks[id] = mu
+	}
+
+	return mu
+}
+
+// Grant issues a new grant to the player with the given ID.
+// The grant is persisted, added to the cached tracker of the player
+// and announced to the other instances. A group cannot be granted while
+// it is being deleted, see HandleGroupDelete.
+func (s *ServiceImpl) Grant(playerID, key, value, addedBy string, expiresAt time.Time, scopes []string) (*model.GrantInfo, error) {
+	if s.store == nil {
+		return nil, errors.New("no grant store")
+	} else if playerID == "" {
+		return nil, fmt.Errorf("%w: no player ID provided", ErrInvalidGrant)
+	} else if key == "" {
+		return nil, fmt.Errorf("%w: no key provided", ErrInvalidGrant)
+	} else if value == "" {
+		return nil, fmt.Errorf("%w: no value provided", ErrInvalidGrant)
+	} else if addedBy == "" {
+		return nil, fmt.Errorf("%w: no added by provided", ErrInvalidGrant)
+	} else if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires at is in the past", ErrInvalidGrant)
+	}
+
+	for _, scope := range scopes {
+		if strings.TrimSpace(scope) == "" {
+			return nil, fmt.Errorf("%w: empty scope provided", ErrInvalidGrant)
+		}
+	}
+
+	if key == model.KeyGroup {
+		mu := s.groupLock(value)
+		mu.RLock()
+		defer mu.RUnlock()
+
+		if s.deps.Groups.LookupByID(value) == nil {
+			return nil, fmt.Errorf("%w: group '%s' does not exist", ErrInvalidGrant, value)
+		}
+	}
+
+	gi := model.NewGrantInfo(
+		uuid.New().String(),
+		playerID,
+		model.NewGrant(key, value),
+		addedBy,
+		time.Now(),
+		expiresAt,
+		scopes,
+	)
+	if err := s.store.Insert(gi); err != nil {
+		return nil, err
+	}
+
+	s.addCached(playerID, gi)
+
+	s.deps.Publish(
+		SubjectGrant,
 		map[string]interface{}{
 			"service_id": helper.ServiceId,
-			"player_id":  pi.ID(),
-			"body":       body,
+			"player_id":  playerID,
+			"body":       gi.Marshal(),
 		},
 	)
 
-	return body, nil
+	s.deps.Audit.Record(audit.ActionGrantIssue, addedBy, playerID, groupOf(gi), nil, gi.Marshal())
+
+	helper.Log.Info(helper.ServiceId+": successfully issued grant", "id", gi.ID(), "player_id", playerID, "key", key, "value", value)
+
+	return gi, nil
 }
 
-// Hook initializes the service.
-func (s *ServiceImpl) Hook() error {
-	if s.ttlSet != nil {
-		return errors.New("GrantsX: TTL set already set")
-	} else if s.col != nil {
-		return errors.New("GrantsX: mongo collection already set")
+// HandleGrant handles the issue of a new grant to the player with the given ID, see Grant.
+// It returns nil if the player does not exist.
+func (s *ServiceImpl) HandleGrant(id, key, value, addedBy string, expiresAt time.Time, scopes []string) (*model.GrantInfo, error) {
+	pi, err := s.deps.Players.UnsafeLookupByID(id)
+	if err != nil || pi == nil {
+		return nil, err
+	}
+
+	return s.Grant(pi.ID(), key, value, addedBy, expiresAt, scopes)
+}
+
+// Revoke revokes the grant with the given ID.
+// The grant is moved from the active grants of the cached tracker
+// to the expired grants and the change is announced to the other instances.
+func (s *ServiceImpl) Revoke(grantID, revokedBy, reason string) (*model.GrantInfo, error) {
+	if s.store == nil {
+		return nil, errors.New("no grant store")
+	} else if grantID == "" {
+		return nil, fmt.Errorf("%w: no grant ID provided", ErrInvalidGrant)
+	} else if revokedBy == "" {
+		return nil, fmt.Errorf("%w: no revoked by provided", ErrInvalidGrant)
+	} else if reason == "" {
+		return nil, fmt.Errorf("%w: no reason provided", ErrInvalidGrant)
+	}
+
+	gi, err := s.store.FindByID(grantID)
+	if err != nil {
+		return nil, err
+	} else if gi.RevokedBy() != "" {
+		return nil, ErrGrantRevoked
+	}
+
+	before := gi.Marshal()
+	revokedAt := time.Now()
+	if err = s.store.Revoke(grantID, revokedBy, revokedAt, reason); err != nil {
+		return nil, err // ErrGrantRevoked if another instance revoked it first.
+	}
+
+	gi.SetRevokedBy(revokedBy)
+	gi.SetRevokedAt(revokedAt)
+	gi.SetRevokeReason(reason)
+
+	s.revokeCached(gi)
+
+	s.deps.Publish(
+		SubjectRevoke,
+		map[string]interface{}{
+			"service_id": helper.ServiceId,
+			"player_id":  gi.SourceID(),
+			"grant_id":   grantID,
+			"revoked_by": revokedBy,
+			"revoked_at": revokedAt.Unix(),
+			"reason":     reason,
+			"body":       gi.Marshal(),
+		},
+	)
+
+	s.deps.Audit.Record(audit.ActionGrantRevoke, revokedBy, gi.SourceID(), groupOf(gi), before, gi.Marshal())
+
+	helper.Log.Info(helper.ServiceId+": successfully revoked grant", "id", grantID, "player_id", gi.SourceID(), "revoked_by", revokedBy)
+
+	return gi, nil
+}
+
+// Reassign changes the value of the grant with the given ID, keeping its key.
+// The change is persisted, announced to the other instances and recorded as made by the actor.
+func (s *ServiceImpl) Reassign(grantID, value, actor string) (*model.GrantInfo, error) {
+	if s.store == nil {
+		return nil, errors.New("no grant store")
+	} else if grantID == "" {
+		return nil, fmt.Errorf("%w: no grant ID provided", ErrInvalidGrant)
+	} else if value == "" {
+		return nil, fmt.Errorf("%w: no value provided", ErrInvalidGrant)
+	} else if actor == "" {
+		return nil, fmt.Errorf("%w: no actor provided", ErrInvalidGrant)
+	}
+
+	gi, err := s.store.FindByID(grantID)
+	if err != nil {
+		return nil, err
+	} else if gi.RevokedBy() != "" {
+		return nil, ErrGrantRevoked
+	}
+
+	before := gi.Marshal()
+	if gi, err = s.store.SetGrantValue(grantID, value); err != nil {
+		return nil, err // ErrGrantRevoked if it was revoked meanwhile.
 	}
 
-	// caching the context helps a lot with performance and memory usage
-	s.ctx = context.Background()
+	s.replaceCached(gi)
 
+	s.deps.Publish(
+		SubjectUpdate,
+		map[string]interface{}{
+			"service_id": helper.ServiceId,
+			"player_id":  gi.SourceID(),
+			"body":       gi.Marshal(),
+		},
+	)
+
+	s.deps.Audit.Record(audit.ActionGrantReassign, actor, gi.SourceID(), groupOf(gi), before, gi.Marshal())
+
+	helper.Log.Info(helper.ServiceId+": successfully reassigned grant", "id", grantID, "player_id", gi.SourceID(), "value", value)
+
+	return gi, nil
+}
+
+// SetExpiry changes when the grant with the given ID expires, the zero time making it permanent.
+// The change is persisted, announced to the other instances and recorded as made by the actor.
+func (s *ServiceImpl) SetExpiry(grantID string, expiresAt time.Time, actor string) (*model.GrantInfo, error) {
+	return s.changeExpiry(grantID, actor, func(time.Time) (time.Time, error) {
+		return expiresAt, nil
+	})
+}
+
+// ExtendExpiry moves when the grant with the given ID expires by the given duration,
+// which shortens the grant if negative. Permanent grants cannot be extended.
+// The change is persisted, announced to the other instances and recorded as made by the actor.
+func (s *ServiceImpl) ExtendExpiry(grantID string, by time.Duration, actor string) (*model.GrantInfo, error) {
+	return s.changeExpiry(grantID, actor, func(current time.Time) (time.Time, error) {
+		if current.IsZero() || current.Unix() <= 0 {
+			return time.Time{}, fmt.Errorf("%w: grant never expires", ErrInvalidGrant)
+		}
+
+		return current.Add(by), nil
+	})
+}
+
+// changeExpiry sets the expiry of the grant with the given ID to the one returned by fn
+// for its current expiry. Revoked and expired grants cannot be changed.
+func (s *ServiceImpl) changeExpiry(grantID, actor string, fn func(current time.Time) (time.Time, error)) (*model.GrantInfo, error) {
+	if s.store == nil {
+		return nil, errors.New("no grant store")
+	} else if grantID == "" {
+		return nil, fmt.Errorf("%w: no grant ID provided", ErrInvalidGrant)
+	} else if actor == "" {
+		return nil, fmt.Errorf("%w: no actor provided", ErrInvalidGrant)
+	}
+
+	gi, err := s.store.FindByID(grantID)
+	if err != nil {
+		return nil, err
+	} else if gi.RevokedBy() != "" {
+		return nil, ErrGrantRevoked
+	} else if gi.Expired() {
+		return nil, fmt.Errorf("%w: grant already expired", ErrInvalidGrant)
+	}
+
+	expiresAt, err := fn(gi.ExpiresAt())
+	if err != nil {
+		return nil, err
+	} else if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires at is in the past", ErrInvalidGrant)
+	}
+
+	before := gi.Marshal()
+	if gi, err = s.store.SetExpiresAt(grantID, expiresAt); err != nil {
+		return nil, err // ErrGrantRevoked if it was revoked meanwhile.
+	}
+
+	s.replaceCached(gi) // Also reschedules the sweeper if the grant now expires earlier.
+
+	s.deps.Publish(
+		SubjectUpdate,
+		map[string]interface{}{
+			"service_id": helper.ServiceId,
+			"player_id":  gi.SourceID(),
+			"body":       gi.Marshal(),
+		},
+	)
+
+	s.deps.Audit.Record(audit.ActionGrantExtend, actor, gi.SourceID(), groupOf(gi), before, gi.Marshal())
+
+	helper.Log.Info(helper.ServiceId+": successfully changed grant expiry", "id", grantID, "player_id", gi.SourceID(), "expires_at", expiresAt, "actor", actor)
+
+	return gi, nil
+}
+
+// groupOf returns the ID of the group granted by the grant, empty if it is not a group grant.
+func groupOf(gi *model.GrantInfo) string {
+	if grant := gi.Grant(); grant.Key() == model.KeyGroup {
+		return grant.Value()
+	}
+
+	return ""
+}
+
+// ActivesByGrant returns the active grants of every player with the given key and value.
+func (s *ServiceImpl) ActivesByGrant(key, value string) ([]*model.GrantInfo, error) {
+	if s.store == nil {
+		return nil, errors.New("no grant store")
+	}
+
+	grants, err := s.store.FindByGrant(key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	var actives []*model.GrantInfo
+	for _, gi := range grants {
+		if !gi.Expired() {
+			actives = append(actives, gi)
+		}
+	}
+
+	return actives, nil
+}
+
+// patchCached applies fn to the cached tracker of the player with the given ID, if any.
+// A load of the tracker in flight is marked stale instead, so it queries the store again
+// rather than caching a tracker missing the change, see UnsafeLookup.
+func (s *ServiceImpl) patchCached(playerID string, fn func(t *model.Tracker)) {
+	s.loadsMu.Lock()
+	defer s.loadsMu.Unlock()
+
+	if l, ok := s.loads[playerID]; ok {
+		l.stale = true
+	} else if t := s.Lookup(playerID); t != nil {
+		fn(t)
+	}
+}
+
+// addCached adds the new active grant to the cached tracker of the player, if any
+// and unless it has it already. A revoked grant, or one the tracker already expired
+// because its revocation was announced first, is never added back.
+func (s *ServiceImpl) addCached(playerID string, gi *model.GrantInfo) {
+	s.patchCached(playerID, func(t *model.Tracker) {
+		if gi.RevokedBy() != "" || t.LookupExpired(gi.ID()) != nil {
+			return
+		} else if t.LookupActive(gi.ID()) == nil {
+			t.AddActive(gi)
+			s.scheduleExpiry(gi.ExpiresAt())
+		}
+	})
+}
+
+// replaceCached replaces the active grant with the same ID in the cached tracker, if any.
+// A grant the tracker already expired, e.g. revoked before the change was announced, is kept expired.
+func (s *ServiceImpl) replaceCached(gi *model.GrantInfo) {
+	s.patchCached(gi.SourceID(), func(t *model.Tracker) {
+		if t.LookupExpired(gi.ID()) != nil {
+			return
+		} else if old := t.LookupActive(gi.ID()); old != nil {
+			t.RemoveActive(old)
+		}
+
+		if gi.Expired() {
+			t.AddExpired(*gi)
+		} else {
+			t.AddActive(gi)
+			s.scheduleExpiry(gi.ExpiresAt())
+		}
+	})
+}
+
+// revokeCached moves the revoked grant from the active grants to the expired grants
+// of the cached tracker, if any. The revoked grant is added as a copy, while readers
+// may still hold the active one. It is added even if the grant is not active yet,
+// so the grant is not added back if its announcement arrives after the revocation.
+func (s *ServiceImpl) revokeCached(revoked *model.GrantInfo) {
+	s.patchCached(revoked.SourceID(), func(t *model.Tracker) {
+		if t.LookupExpired(revoked.ID()) != nil {
+			return
+		} else if gi := t.LookupActive(revoked.ID()); gi != nil {
+			t.RemoveActive(gi)
+		}
+
+		t.AddExpired(*revoked)
+	})
+}
+
+// scheduleExpiry makes sure the sweeper runs no later than the given time.
+// The zero time is ignored because it means the grant never expires.
+func (s *ServiceImpl) scheduleExpiry(at time.Time) {
+	if at.IsZero() || at.Unix() <= 0 {
+		return
+	}
+
+	s.expiryMu.Lock()
+	defer s.expiryMu.Unlock()
+
+	if s.expiryTimer != nil && !at.Before(s.expiryAt) {
+		return // The sweeper already runs before the given time.
+	} else if s.expiryTimer != nil {
+		s.expiryTimer.Stop()
+	}
+
+	s.expiryAt = at
+	s.expiryTimer = time.AfterFunc(time.Until(at), s.sweepExpired)
+}
+
+// sweepExpired moves the expired grants of every cached tracker
+// to the expired grants, announces them and schedules the next sweep.
+func (s *ServiceImpl) sweepExpired() {
+	s.expiryMu.Lock()
+	s.expiryTimer = nil
+	s.expiryAt = time.Time{}
+	s.expiryMu.Unlock()
+
+	s.mu.RLock()
+	trackers := make([]*model.Tracker, 0, len(s.trackers))
+	for _, t := range s.trackers {
+		trackers = append(trackers, t)
+	}
+	s.mu.RUnlock()
+
+	var next time.Time
+	for _, t := range trackers {
+		for _, gi := range t.SweepExpired() {
+			s.deps.Publish(
+				SubjectExpired,
+				map[string]interface{}{
+					"service_id": helper.ServiceId,
+					"player_id":  t.ID(),
+					"grant_id":   gi.ID(),
+					"body":       gi.Marshal(),
+				},
+			)
+
+			helper.Log.Info(helper.ServiceId+": grant expired", "id", gi.ID(), "player_id", t.ID())
+		}
+
+		if at := t.NextExpiry(); !at.IsZero() && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+
+	s.scheduleExpiry(next)
+}
+
+// hookTTLSet creates the TTL set that clears the trackers of the offline players.
+func (s *ServiceImpl) hookTTLSet() {
 	s.ttlSet = Quark.NewSet(
 		1*time.Hour,
 		1*time.Hour,
@@ -167,7 +673,7 @@ func (s *ServiceImpl) Hook() error {
 			return
 		}
 
-		pi := Zurita.Service().LookupByID(id)
+		pi := s.deps.Players.LookupByID(id)
 		if pi != nil && pi.Online() {
 			return // No clear the tracker if the player is online.
 		}
@@ -176,14 +682,126 @@ func (s *ServiceImpl) Hook() error {
 		delete(s.trackers, id)
 		s.mu.Unlock()
 	})
+}
+
+// Hook initializes the service.
+// The audit service must be hooked first so no change goes unrecorded.
+func (s *ServiceImpl) Hook() error {
+	if s.ttlSet != nil {
+		return errors.New("GrantsX: TTL set already set")
+	} else if s.store != nil {
+		return errors.New("GrantsX: grant store already set")
+	} else if helper.NatsClient == nil {
+		return errors.New("GrantsX: nats client not set")
+	} else if !s.deps.Audit.Hooked() {
+		return errors.New("GrantsX: audit service not hooked")
+	}
+
+	store, err := newStore()
+	if err != nil {
+		return errors.Join(errors.New("GrantsX: failed to open the grant store"), err)
+	}
 
-	s.col = helper.MongoClient.Database(helper.MongoDBName).Collection("grants")
+	s.hookTTLSet()
+	s.store = store
 
 	Zurita.Service().SetNatsHandler(NatsHandler{})
 
+	if _, err = helper.NatsClient.Subscribe(SubjectGrant, s.natsGrant); err != nil {
+		return errors.Join(errors.New("GrantsX: failed to subscribe to grant"), err)
+	} else if _, err = helper.NatsClient.Subscribe(SubjectRevoke, s.natsRevoke); err != nil {
+		return errors.Join(errors.New("GrantsX: failed to subscribe to revoke"), err)
+	} else if _, err = helper.NatsClient.Subscribe(SubjectUpdate, s.natsUpdate); err != nil {
+		return errors.Join(errors.New("GrantsX: failed to subscribe to update"), err)
+	}
+
 	return nil
 }
 
+// natsGrant adds a grant issued by another instance to the cached tracker.
+func (s *ServiceImpl) natsGrant(msg *nats.Msg) {
+	var body map[string]interface{}
+	if err := sonic.Unmarshal(msg.Data, &body); err != nil {
+		helper.Log.Error("nats: failed to unmarshal grant message", "err", err)
+	} else if servID, ok := body["service_id"].(string); !ok {
+		helper.Log.Error("nats: grant message missing service ID")
+	} else if servID == helper.ServiceId {
+		return // The grant was already added by this instance.
+	} else if playerID, ok := body["player_id"].(string); !ok {
+		helper.Log.Error("nats: grant message missing player ID")
+	} else if giBody, ok := body["body"].(map[string]interface{}); !ok {
+		helper.Log.Error("nats: grant message missing body")
+	} else {
+		gi := &model.GrantInfo{}
+		if err := gi.Unmarshal(giBody); err != nil {
+			helper.Log.Error("nats: failed to unmarshal grant", "err", err, "body", giBody)
+		} else {
+			s.addCached(playerID, gi) // A tracker not cached yet is loaded with it on the next lookup.
+		}
+	}
+}
+
+// natsUpdate replaces a grant updated by another instance in the cached tracker.
+func (s *ServiceImpl) natsUpdate(msg *nats.Msg) {
+	var body map[string]interface{}
+	if err := sonic.Unmarshal(msg.Data, &body); err != nil {
+		helper.Log.Error("nats: failed to unmarshal update message", "err", err)
+	} else if servID, ok := body["service_id"].(string); !ok {
+		helper.Log.Error("nats: update message missing service ID")
+	} else if servID == helper.ServiceId {
+		return // The grant was already updated by this instance.
+	} else if giBody, ok := body["body"].(map[string]interface{}); !ok {
+		helper.Log.Error("nats: update message missing body")
+	} else {
+		gi := &model.GrantInfo{}
+		if err := gi.Unmarshal(giBody); err != nil {
+			helper.Log.Error("nats: failed to unmarshal grant", "err", err, "body", giBody)
+		} else {
+			s.replaceCached(gi)
+		}
+	}
+}
+
+// natsRevoke moves a grant revoked by another instance to the expired grants.
+func (s *ServiceImpl) natsRevoke(msg *nats.Msg) {
+	var body map[string]interface{}
+	if err := sonic.Unmarshal(msg.Data, &body); err != nil {
+		helper.Log.Error("nats: failed to unmarshal revoke message", "err", err)
+	} else if servID, ok := body["service_id"].(string); !ok {
+		helper.Log.Error("nats: revoke message missing service ID")
+	} else if servID == helper.ServiceId {
+		return // The grant was already revoked by this instance.
+	} else if giBody, ok := body["body"].(map[string]interface{}); !ok {
+		helper.Log.Error("nats: revoke message missing body")
+	} else {
+		gi := &model.GrantInfo{}
+		if err := gi.Unmarshal(giBody); err != nil {
+			helper.Log.Error("nats: failed to unmarshal grant", "err", err, "body", giBody)
+		} else if gi.RevokedBy() == "" {
+			helper.Log.Error("nats: revoke message of a grant not revoked", "id", gi.ID())
+		} else {
+			s.revokeCached(gi)
+		}
+	}
+}
+
+// NewService returns a service backed by the given store and dependencies. Unlike Hook
+// it neither connects to MongoDB nor subscribes to NATS, which makes it suitable for
+// tests and tools embedding the grants service, see MemoryStore.
+func NewService(store GrantStore, deps Dependencies) *ServiceImpl {
+	s := &ServiceImpl{
+		trackers: make(map[string]*model.Tracker),
+		loads:    make(map[string]*load),
+		store:    store,
+		deps:     deps.withDefaults(),
+
+		groupLocks: make(map[string]*sync.RWMutex),
+	}
+	s.hookTTLSet()
+
+	return s
+}
+
 // Service returns the service.
 func Service() *ServiceImpl {
 	return service
@@ -191,5 +809,32 @@ func Service() *ServiceImpl {
 
 var service = &ServiceImpl{
 	trackers: make(map[string]*model.Tracker),
+	loads:    make(map[string]*load),
+	deps:     Dependencies{}.withDefaults(),
+
+	groupLocks: make(map[string]*sync.RWMutex),
 }
-var SubjectLookup = "kyro:grants_lookup"
+var (
+	SubjectLookup = "kyro:grants_lookup"
+	SubjectGrant  = "kyro:grants_grant"
+	SubjectRevoke = "kyro:grants_revoke"
+	SubjectUpdate = "kyro:grants_update"
+	// SubjectExpired is published by every instance holding the tracker
+	// when one of its grants expires, so subscribers must be idempotent.
+	SubjectExpired = "kyro:grants_expired"
+)
+
+var (
+	// ErrInvalidGrant is returned when a grant operation is called with invalid input.
+	ErrInvalidGrant = errors.New("invalid grant")
+	// ErrGrantNotFound is returned when no grant exists with the given ID.
+	ErrGrantNotFound = errors.New("grant not found")
+	// ErrGrantRevoked is returned when the grant was already revoked.
+	ErrGrantRevoked = errors.New("grant already revoked")
+	// ErrInvalidPolicy is returned when a group deletion is requested with an invalid policy.
+	ErrInvalidPolicy = errors.New("invalid policy")
+	// ErrGroupInUse is returned when a group cannot be deleted because it is still granted.
+	ErrGroupInUse = errors.New("group in use")
+	// ErrInvalidQuery is returned when the holders of a grant are looked up with invalid input.
+	ErrInvalidQuery = errors.New("invalid query")
+)
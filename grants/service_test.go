@@ -0,0 +1,599 @@
+package grants
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/audit"
+	"github.com/Mides-Projects/Kyro/bgroups"
+	bgmodel "github.com/Mides-Projects/Kyro/bgroups/model"
+	"github.com/Mides-Projects/Kyro/grants/model"
+	"github.com/bytedance/sonic"
+	"github.com/nats-io/nats.go"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakePlayer is a Player known by fakePlayers.
+type fakePlayer struct {
+	id     string
+	name   string
+	online bool
+}
+
+func (p fakePlayer) ID() string   { return p.id }
+func (p fakePlayer) Name() string { return p.name }
+func (p fakePlayer) Online() bool { return p.online }
+
+// fakePlayers is a PlayerLookup of the players keyed by ID.
+type fakePlayers map[string]fakePlayer
+
+func (f fakePlayers) LookupByID(id string) Player {
+	if p, ok := f[id]; ok {
+		return p
+	}
+
+	return nil
+}
+
+func (f fakePlayers) UnsafeLookupByID(id string) (Player, error) {
+	return f.LookupByID(id), nil
+}
+
+func (f fakePlayers) UnsafeLookupByName(name string) (Player, error) {
+	for _, p := range f {
+		if strings.EqualFold(p.name, name) {
+			return p, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// fakeGroups is a GroupService of the groups keyed by ID.
+type fakeGroups struct {
+	values map[string]*bgmodel.Group
+	mu     sync.Mutex
+}
+
+func (f *fakeGroups) LookupByID(id string) *bgmodel.Group {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.values[id]
+}
+
+func (f *fakeGroups) ResolvePermission(id, node string, contexts map[string]string) *bgroups.Resolution {
+	g := f.LookupByID(id)
+	if g == nil {
+		return nil
+	}
+
+	allowed, entry, ok := bgroups.MatchPermission(g.Permissions(), node, contexts)
+	if !ok {
+		return nil
+	}
+
+	return &bgroups.Resolution{Allowed: allowed, GroupID: id, Entry: entry}
+}
+
+func (f *fakeGroups) Delete(id, _ string) error {
+	f.mu.Lock()
+	delete(f.values, id)
+	f.mu.Unlock()
+
+	return nil
+}
+
+// testEnv is a grants service running without MongoDB, NATS or Zurita.
+type testEnv struct {
+	s       *ServiceImpl
+	store   *MemoryStore
+	groups  *fakeGroups
+	audit   *audit.ServiceImpl
+	players fakePlayers
+}
+
+func newTestEnv() *testEnv {
+	env := &testEnv{
+		store: NewMemoryStore(),
+		groups: &fakeGroups{values: map[string]*bgmodel.Group{
+			"admin":  bgmodel.NewGroup("admin", "Admin"),
+			"member": bgmodel.NewGroup("member", "Member"),
+		}},
+		audit: audit.NewService(audit.NewMemoryStore()),
+		players: fakePlayers{
+			"p1": {id: "p1", name: "Steve", online: true},
+			"p2": {id: "p2", name: "Alex"},
+		},
+	}
+	env.groups.values["admin"].SetWeight(100)
+
+	env.s = NewService(env.store, Dependencies{
+		Players: env.players,
+		Groups:  env.groups,
+		Audit:   env.audit,
+		Publish: func(string, map[string]interface{}) {},
+	})
+
+	return env
+}
+
+func TestUnsafeLookupSplitsActiveAndExpired(t *testing.T) {
+	env := newTestEnv()
+
+	past := model.NewGrantInfo("g1", "p1", model.NewGrant(model.KeyGroup, "member"), "console", time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), nil)
+	active := model.NewGrantInfo("g2", "p1", model.NewGrant(model.KeyGroup, "admin"), "console", time.Now(), time.Time{}, nil)
+	other := model.NewGrantInfo("g3", "p2", model.NewGrant(model.KeyGroup, "admin"), "console", time.Now(), time.Time{}, nil)
+	for _, gi := range []*model.GrantInfo{past, active, other} {
+		if err := env.store.Insert(gi); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tr, err := env.s.UnsafeLookup("p1")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(tr.Actives()) != 1 || tr.Actives()[0].ID() != "g2" {
+		t.Fatalf("actives = %v, want [g2]", tr.Actives())
+	} else if len(tr.Expired()) != 1 || tr.Expired()[0].ID() != "g1" {
+		t.Fatalf("expired = %v, want [g1]", tr.Expired())
+	}
+}
+
+func TestUnsafeLookupSharesInFlightLoad(t *testing.T) {
+	s := &ServiceImpl{
+		trackers: make(map[string]*model.Tracker),
+		loads:    make(map[string]*load),
+	}
+
+	// The load of the player is in flight until done is closed.
+	l := &load{done: make(chan struct{})}
+	s.loads["p1"] = l
+
+	const lookups = 16
+
+	var wg sync.WaitGroup
+	trackers := make([]*model.Tracker, lookups)
+	for i := range lookups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			tr, err := s.UnsafeLookup("p1")
+			if err != nil {
+				t.Error(err)
+			}
+			trackers[i] = tr
+		}()
+	}
+
+	// Let the lookups pile up on the load before it finishes.
+	time.Sleep(50 * time.Millisecond)
+	l.t = model.NewTracker("p1")
+	close(l.done)
+	wg.Wait()
+
+	for _, tr := range trackers {
+		if tr != l.t {
+			t.Fatal("the lookups did not share the in-flight load")
+		}
+	}
+}
+
+func TestGrantThenHandleLookup(t *testing.T) {
+	env := newTestEnv()
+
+	if _, err := env.s.Grant("p1", model.KeyGroup, "member", "console", time.Time{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	gi, err := env.s.Grant("p1", model.KeyGroup, "admin", "console", time.Now().Add(time.Hour), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := env.s.HandleLookup("steve", false, false, "")
+	if err != nil {
+		t.Fatal(err)
+	} else if body == nil {
+		t.Fatal("HandleLookup returned no body for a known player")
+	}
+
+	if actives := body["actives"].(map[string]interface{}); len(actives) != 2 || actives[gi.ID()] == nil {
+		t.Errorf("actives = %v, want the 2 issued grants", actives)
+	}
+	if primary, _ := body["primary_group"].(map[string]interface{}); primary == nil || primary["_id"] != "admin" {
+		t.Errorf("primary_group = %v, want admin", body["primary_group"])
+	}
+
+	entries, _, err := env.audit.Query(audit.Filter{PlayerID: "p1"}, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 || entries[0].Action() != audit.ActionGrantIssue || entries[0].Actor() != "console" {
+		t.Errorf("audit entries = %d, want 2 grant issues by console", len(entries))
+	}
+}
+
+func TestHandleGrant(t *testing.T) {
+	env := newTestEnv()
+
+	if gi, err := env.s.HandleGrant("nobody", model.KeyGroup, "admin", "console", time.Time{}, nil); err != nil || gi != nil {
+		t.Fatalf("HandleGrant to an unknown player = %v, %v, want nil, nil", gi, err)
+	}
+
+	gi, err := env.s.HandleGrant("p2", model.KeyGroup, "admin", "console", time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if gi == nil || gi.SourceID() != "p2" {
+		t.Fatalf("HandleGrant = %v, want a grant of p2", gi)
+	}
+}
+
+func TestHandleLookupPublishesEveryGrant(t *testing.T) {
+	env := newTestEnv()
+
+	published := make(chan map[string]interface{}, 1)
+	env.s.deps.Publish = func(subject string, body map[string]interface{}) {
+		if subject == SubjectLookup {
+			published <- body["body"].(map[string]interface{})
+		}
+	}
+
+	if _, err := env.s.Grant("p1", model.KeyGroup, "admin", "console", time.Time{}, []string{"lobby"}); err != nil {
+		t.Fatal(err)
+	} else if _, err = env.s.Grant("p1", model.KeyGroup, "member", "console", time.Time{}, []string{"bedwars"}); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := env.s.HandleLookup("p1", true, false, "lobby")
+	if err != nil {
+		t.Fatal(err)
+	} else if actives := body["actives"].(map[string]interface{}); len(actives) != 1 {
+		t.Errorf("actives = %d, want the grant of the scope", len(actives))
+	}
+
+	if actives := (<-published)["actives"].(map[string]interface{}); len(actives) != 2 {
+		t.Errorf("published actives = %d, want every grant", len(actives))
+	}
+}
+
+func TestGrantRejectsInvalidInput(t *testing.T) {
+	env := newTestEnv()
+
+	tests := []struct {
+		name      string
+		key       string
+		value     string
+		addedBy   string
+		expiresAt time.Time
+	}{
+		{"unknown group", model.KeyGroup, "owner", "console", time.Time{}},
+		{"no key", "", "admin", "console", time.Time{}},
+		{"no added by", model.KeyGroup, "admin", "", time.Time{}},
+		{"expired", model.KeyGroup, "admin", "console", time.Now().Add(-time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := env.s.Grant("p1", tt.key, tt.value, tt.addedBy, tt.expiresAt, nil); !errors.Is(err, ErrInvalidGrant) {
+				t.Errorf("err = %v, want ErrInvalidGrant", err)
+			}
+		})
+	}
+}
+
+func TestHandleLookupUnknownPlayer(t *testing.T) {
+	env := newTestEnv()
+
+	if body, err := env.s.HandleLookup("nobody", true, false, ""); err != nil || body != nil {
+		t.Fatalf("HandleLookup = %v, %v, want nil, nil", body, err)
+	}
+}
+
+func TestRevokeMovesGrantToExpired(t *testing.T) {
+	env := newTestEnv()
+
+	gi, err := env.s.Grant("p1", model.KeyGroup, "admin", "console", time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = env.s.HandleLookup("p1", true, false, ""); err != nil {
+		t.Fatal(err)
+	}
+	previous := env.s.Lookup("p1").Actives()
+
+	if _, err = env.s.Revoke(gi.ID(), "moderator", "abuse"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Readers of the actives from before the revocation keep a consistent view.
+	if len(previous) != 1 || previous[0].ID() != gi.ID() || previous[0].RevokedBy() != "" {
+		t.Errorf("previous actives = %v, want the grant unchanged", previous)
+	}
+
+	if tr := env.s.Lookup("p1"); tr == nil {
+		t.Fatal("tracker is not cached")
+	} else if len(tr.Actives()) != 0 {
+		t.Errorf("actives = %v, want none", tr.Actives())
+	} else if expired := tr.Expired(); len(expired) != 1 || expired[0].RevokedBy() != "moderator" {
+		t.Errorf("expired = %v, want the grant revoked by moderator", expired)
+	}
+
+	stored, err := env.store.FindByID(gi.ID())
+	if err != nil {
+		t.Fatal(err)
+	} else if stored.RevokedBy() != "moderator" || stored.RevokeReason() != "abuse" {
+		t.Errorf("stored grant revoked by %q for %q", stored.RevokedBy(), stored.RevokeReason())
+	}
+
+	if _, err = env.s.Revoke(gi.ID(), "moderator", "abuse"); !errors.Is(err, ErrGrantRevoked) {
+		t.Errorf("second revoke err = %v, want ErrGrantRevoked", err)
+	}
+	if _, err = env.s.Revoke("missing", "moderator", "abuse"); !errors.Is(err, ErrGrantNotFound) {
+		t.Errorf("revoke of a missing grant err = %v, want ErrGrantNotFound", err)
+	}
+}
+
+// natsMsg returns the message announcing a change made by another instance.
+func natsMsg(t *testing.T, body map[string]interface{}) *nats.Msg {
+	t.Helper()
+
+	body["service_id"] = "other"
+	data, err := sonic.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &nats.Msg{Data: data}
+}
+
+func TestRevocationAnnouncedFirstIsKept(t *testing.T) {
+	env := newTestEnv()
+
+	if _, err := env.s.HandleLookup("p1", true, false, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	gi := model.NewGrantInfo("g1", "p1", model.NewGrant(model.KeyGroup, "admin"), "console", time.Now(), time.Time{}, nil)
+	revoked := *gi
+	revoked.SetRevokedBy("moderator")
+	revoked.SetRevokedAt(time.Now())
+	revoked.SetRevokeReason("abuse")
+
+	// The revocation made by another instance arrives before the grant and its update.
+	env.s.natsRevoke(natsMsg(t, map[string]interface{}{"player_id": "p1", "grant_id": "g1", "body": revoked.Marshal()}))
+	env.s.natsGrant(natsMsg(t, map[string]interface{}{"player_id": "p1", "body": gi.Marshal()}))
+	env.s.natsUpdate(natsMsg(t, map[string]interface{}{"player_id": "p1", "body": gi.Marshal()}))
+
+	if tr := env.s.Lookup("p1"); len(tr.Actives()) != 0 {
+		t.Errorf("actives = %v, want none", tr.Actives())
+	} else if expired := tr.Expired(); len(expired) != 1 || expired[0].RevokedBy() != "moderator" {
+		t.Errorf("expired = %v, want the grant revoked by moderator", expired)
+	}
+}
+
+// countingStore is a GrantStore counting the tracker queries, which block until release is closed.
+type countingStore struct {
+	*MemoryStore
+
+	queries atomic.Int32
+	release chan struct{}
+}
+
+func (cs *countingStore) FindByPlayer(playerID string) ([]*model.GrantInfo, error) {
+	cs.queries.Add(1)
+	<-cs.release
+
+	return cs.MemoryStore.FindByPlayer(playerID)
+}
+
+func TestUnsafeLookupCoalescesQueries(t *testing.T) {
+	env := newTestEnv()
+	store := &countingStore{MemoryStore: env.store, release: make(chan struct{})}
+	env.s.store = store
+
+	const lookups = 16
+
+	var wg sync.WaitGroup
+	trackers := make([]*model.Tracker, lookups)
+	for i := range lookups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			tr, err := env.s.UnsafeLookup("p1")
+			if err != nil {
+				t.Error(err)
+			}
+			trackers[i] = tr
+		}()
+	}
+
+	// Let the lookups pile up on the first query before it returns.
+	for store.queries.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(store.release)
+	wg.Wait()
+
+	if n := store.queries.Load(); n != 1 {
+		t.Errorf("queries = %d, want 1", n)
+	}
+	for _, tr := range trackers {
+		if tr == nil || tr != trackers[0] {
+			t.Fatal("the lookups did not share the same tracker")
+		}
+	}
+
+	if _, err := env.s.UnsafeLookup("p1"); err != nil {
+		t.Fatal(err)
+	} else if n := store.queries.Load(); n != 1 {
+		t.Errorf("queries after the load = %d, want the tracker to be cached", n)
+	} else if env.s.Lookup("p1") != trackers[0] {
+		t.Error("the loaded tracker is not cached")
+	}
+}
+
+// snapshotStore is a GrantStore whose first tracker query reads the grants
+// and then blocks until release is closed, as a slow query would.
+type snapshotStore struct {
+	*MemoryStore
+
+	once    sync.Once
+	queried chan struct{}
+	release chan struct{}
+}
+
+func (ss *snapshotStore) FindByPlayer(playerID string) ([]*model.GrantInfo, error) {
+	grants, err := ss.MemoryStore.FindByPlayer(playerID)
+	ss.once.Do(func() {
+		close(ss.queried)
+		<-ss.release
+	})
+
+	return grants, err
+}
+
+func TestWritesDuringLoadAreNotLost(t *testing.T) {
+	env := newTestEnv()
+
+	revoked, err := env.s.Grant("p1", model.KeyGroup, "member", "console", time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &snapshotStore{MemoryStore: env.store, queried: make(chan struct{}), release: make(chan struct{})}
+	env.s.store = store
+
+	type result struct {
+		t   *model.Tracker
+		err error
+	}
+	loaded := make(chan result)
+	go func() {
+		tr, err := env.s.UnsafeLookup("p1")
+		loaded <- result{tr, err}
+	}()
+
+	// The query already read the grants when they change.
+	<-store.queried
+	issued, err := env.s.Grant("p1", model.KeyGroup, "admin", "console", time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err = env.s.Revoke(revoked.ID(), "moderator", "abuse"); err != nil {
+		t.Fatal(err)
+	}
+	close(store.release)
+
+	r := <-loaded
+	if r.err != nil {
+		t.Fatal(r.err)
+	} else if actives := r.t.Actives(); len(actives) != 1 || actives[0].ID() != issued.ID() {
+		t.Errorf("actives = %v, want only the grant issued during the load", actives)
+	} else if expired := r.t.Expired(); len(expired) != 1 || expired[0].ID() != revoked.ID() {
+		t.Errorf("expired = %v, want the grant revoked during the load", expired)
+	}
+}
+
+// revokingStore is a GrantStore revoking every grant right after it is read by ID,
+// as another instance would between the read and the write of a change.
+type revokingStore struct {
+	*MemoryStore
+}
+
+func (rs revokingStore) FindByID(id string) (*model.GrantInfo, error) {
+	gi, err := rs.MemoryStore.FindByID(id)
+	if err == nil {
+		err = rs.MemoryStore.Revoke(id, "moderator", time.Now(), "abuse")
+	}
+
+	return gi, err
+}
+
+func TestSetExpiryKeepsConcurrentRevocation(t *testing.T) {
+	env := newTestEnv()
+
+	gi, err := env.s.Grant("p1", model.KeyGroup, "admin", "console", time.Now().Add(time.Hour), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.s.store = revokingStore{env.store}
+
+	if _, err = env.s.ExtendExpiry(gi.ID(), time.Hour, "console"); !errors.Is(err, ErrGrantRevoked) {
+		t.Errorf("err = %v, want ErrGrantRevoked", err)
+	}
+
+	if stored, err := env.store.FindByID(gi.ID()); err != nil {
+		t.Fatal(err)
+	} else if stored.RevokedBy() != "moderator" {
+		t.Error("the revocation was overwritten")
+	} else if !stored.ExpiresAt().Equal(time.Unix(gi.ExpiresAt().Unix(), 0)) {
+		t.Errorf("expires at = %v, want it unchanged", stored.ExpiresAt())
+	}
+}
+
+func TestReassign(t *testing.T) {
+	env := newTestEnv()
+
+	gi, err := env.s.Grant("p1", model.KeyGroup, "member", "console", time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = env.s.Reassign(gi.ID(), "admin", ""); !errors.Is(err, ErrInvalidGrant) {
+		t.Errorf("reassign without an actor err = %v, want ErrInvalidGrant", err)
+	}
+
+	if reassigned, err := env.s.Reassign(gi.ID(), "admin", "console"); err != nil {
+		t.Fatal(err)
+	} else if grant := reassigned.Grant(); grant.Key() != model.KeyGroup || grant.Value() != "admin" {
+		t.Errorf("grant = %v, want the admin group", reassigned.Marshal()["grant"])
+	}
+
+	// The revocation by another instance between the read and the write is kept.
+	env.s.store = revokingStore{env.store}
+	if _, err = env.s.Reassign(gi.ID(), "member", "console"); !errors.Is(err, ErrGrantRevoked) {
+		t.Errorf("err = %v, want ErrGrantRevoked", err)
+	}
+
+	if stored, err := env.store.FindByID(gi.ID()); err != nil {
+		t.Fatal(err)
+	} else if grant := stored.Grant(); stored.RevokedBy() != "moderator" || grant.Value() != "admin" {
+		t.Errorf("stored grant = %v, want it revoked and still admin", stored.Marshal())
+	}
+}
+
+func TestHandlePermissionCheckScope(t *testing.T) {
+	env := newTestEnv()
+	env.groups.values["admin"].AddPermission("kit.vip")
+	env.groups.values["member"].AddPermission("-kit.vip")
+
+	admin, err := env.s.Grant("p1", model.KeyGroup, "admin", "console", time.Time{}, []string{"lobby-*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	member, err := env.s.Grant("p1", model.KeyGroup, "member", "console", time.Time{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		scope   string
+		allowed bool
+		grantID string
+	}{
+		{"lobby-1", true, admin.ID()},
+		{"bedwars-1", false, member.ID()},
+		{"", true, admin.ID()},
+	}
+	for _, tt := range tests {
+		body, err := env.s.HandlePermissionCheck("p1", "kit.vip", tt.scope, nil)
+		if err != nil {
+			t.Fatal(err)
+		} else if body["allowed"] != tt.allowed || body["grant_id"] != tt.grantID {
+			t.Errorf("scope %q: allowed = %v by %v, want %v by %s", tt.scope, body["allowed"], body["grant_id"], tt.allowed, tt.grantID)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package grants
+
+import (
+	"errors"
+	"github.com/Mides-Projects/Kyro/grants/model"
+	"github.com/Mides-Projects/Kyro/storage"
+	"github.com/Mides-Projects/Operator/helper"
+	"time"
+)
+
+// GrantStore persists the grants of the players.
+type GrantStore interface {
+	// FindByPlayer returns every grant of the player with the given ID, active or not.
+	FindByPlayer(id string) ([]*model.GrantInfo, error)
+	// FindByID returns the grant with the given ID or ErrGrantNotFound.
+	FindByID(id string) (*model.GrantInfo, error)
+	// FindByGrant returns every grant with the given key and value that is not revoked.
+	FindByGrant(key, value string) ([]*model.GrantInfo, error)
+	// FindPage returns a page of the grants matching the query, ordered by ID.
+	FindPage(q GrantQuery) ([]*model.GrantInfo, error)
+	// Insert inserts a new grant.
+	Insert(gi *model.GrantInfo) error
+	// SetGrantValue sets the value of the grant with the given ID unless it is revoked,
+	// and returns the updated grant. It returns ErrGrantNotFound if it does not exist
+	// and ErrGrantRevoked if it is revoked.
+	SetGrantValue(id, value string) (*model.GrantInfo, error)
+	// SetExpiresAt sets when the grant with the given ID expires, the zero time if it never does,
+	// unless it is revoked, and returns the updated grant. It returns ErrGrantNotFound
+	// if it does not exist and ErrGrantRevoked if it is revoked.
+	SetExpiresAt(id string, expiresAt time.Time) (*model.GrantInfo, error)
+	// Revoke marks the grant with the given ID as revoked, or returns ErrGrantNotFound
+	// if it does not exist and ErrGrantRevoked if it was already revoked.
+	Revoke(id, revokedBy string, revokedAt time.Time, reason string) error
+}
+
+// GrantQuery selects a page of the grants with a key and value, see GrantStore.FindPage.
+type GrantQuery struct {
+	Key   string
+	Value string
+	State string // State is StateActive, StateExpired or StateAll.
+	After string // After is the ID of the last grant of the previous page, empty for the first page.
+	Limit int    // Limit is the maximum number of grants of the page.
+}
+
+// newStore returns the GrantStore of the storage driver selected by the configuration.
+func newStore() (GrantStore, error) {
+	switch driver := storage.Driver(); driver {
+	case storage.DriverMongo:
+		ms := NewMongoStore(helper.MongoClient.Database(helper.MongoDBName).Collection("grants"))
+		if err := ms.EnsureIndexes(); err != nil {
+			return nil, err
+		}
+
+		return ms, nil
+	case storage.DriverSQLite:
+		db, err := storage.SQLite()
+		if err != nil {
+			return nil, err
+		}
+
+		return NewSQLiteStore(db), nil
+	default:
+		return nil, errors.New("unknown storage driver '" + driver + "'")
+	}
+}
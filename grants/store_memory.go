@@ -0,0 +1,182 @@
+package grants
+
+import (
+	"github.com/Mides-Projects/Kyro/grants/model"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a GrantStore that keeps the grants in memory.
+// The grants are stored marshaled so callers never share them with the store,
+// which makes it a faithful replacement of MongoStore in tests and tools.
+type MemoryStore struct {
+	values map[string]map[string]interface{}
+	mu     sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		values: make(map[string]map[string]interface{}),
+	}
+}
+
+// FindByPlayer returns every grant of the player with the given ID, active or not.
+func (ms *MemoryStore) FindByPlayer(id string) ([]*model.GrantInfo, error) {
+	return ms.find(func(gi *model.GrantInfo) bool {
+		return gi.SourceID() == id
+	})
+}
+
+// FindByID returns the grant with the given ID or ErrGrantNotFound.
+func (ms *MemoryStore) FindByID(id string) (*model.GrantInfo, error) {
+	ms.mu.RLock()
+	body, ok := ms.values[id]
+	ms.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrGrantNotFound
+	}
+
+	gi := &model.GrantInfo{}
+	if err := gi.Unmarshal(body); err != nil {
+		return nil, err
+	}
+
+	return gi, nil
+}
+
+// FindByGrant returns every grant with the given key and value that is not revoked.
+func (ms *MemoryStore) FindByGrant(key, value string) ([]*model.GrantInfo, error) {
+	return ms.find(func(gi *model.GrantInfo) bool {
+		grant := gi.Grant()
+
+		return grant.Key() == key && grant.Value() == value && gi.RevokedBy() == ""
+	})
+}
+
+// FindPage returns a page of the grants matching the query, ordered by ID.
+func (ms *MemoryStore) FindPage(q GrantQuery) ([]*model.GrantInfo, error) {
+	grants, err := ms.find(func(gi *model.GrantInfo) bool {
+		grant := gi.Grant()
+		if grant.Key() != q.Key || grant.Value() != q.Value || gi.ID() <= q.After {
+			return false
+		}
+
+		return q.State == StateAll || gi.Expired() == (q.State == StateExpired)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(grants, func(a, b *model.GrantInfo) int {
+		return strings.Compare(a.ID(), b.ID())
+	})
+
+	if len(grants) > q.Limit {
+		grants = grants[:q.Limit]
+	}
+
+	return grants, nil
+}
+
+// Insert inserts a new grant.
+func (ms *MemoryStore) Insert(gi *model.GrantInfo) error {
+	ms.mu.Lock()
+	ms.values[gi.ID()] = gi.Marshal()
+	ms.mu.Unlock()
+
+	return nil
+}
+
+// SetGrantValue sets the value of the grant with the given ID unless it is revoked.
+func (ms *MemoryStore) SetGrantValue(id, value string) (*model.GrantInfo, error) {
+	return ms.updateActive(id, func(body map[string]interface{}) {
+		grant, _ := body["grant"].(map[string]interface{})
+		grant = maps.Clone(grant)
+		grant["value"] = value
+		body["grant"] = grant
+	})
+}
+
+// SetExpiresAt sets when the grant with the given ID expires unless it is revoked.
+func (ms *MemoryStore) SetExpiresAt(id string, expiresAt time.Time) (*model.GrantInfo, error) {
+	var at int64
+	if !expiresAt.IsZero() {
+		at = expiresAt.Unix()
+	}
+
+	return ms.updateActive(id, func(body map[string]interface{}) {
+		body["expires_at"] = at
+	})
+}
+
+// Revoke marks the grant with the given ID as revoked.
+func (ms *MemoryStore) Revoke(id, revokedBy string, revokedAt time.Time, reason string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	body, ok := ms.values[id]
+	if !ok {
+		return ErrGrantNotFound
+	} else if _, revoked := body["revoked_at"]; revoked {
+		return ErrGrantRevoked
+	}
+
+	// Copy the body because readers may be unmarshalling it outside the lock.
+	body = maps.Clone(body)
+	body["revoked_by"] = revokedBy
+	body["revoked_at"] = revokedAt.Unix()
+	body["revoke_reason"] = reason
+	ms.values[id] = body
+
+	return nil
+}
+
+// updateActive applies fn to the stored body of the grant with the given ID
+// unless it is revoked, and returns the updated grant.
+func (ms *MemoryStore) updateActive(id string, fn func(body map[string]interface{})) (*model.GrantInfo, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	body, ok := ms.values[id]
+	if !ok {
+		return nil, ErrGrantNotFound
+	} else if _, revoked := body["revoked_at"]; revoked {
+		return nil, ErrGrantRevoked
+	}
+
+	// Copy the body because readers may be unmarshalling it outside the lock.
+	body = maps.Clone(body)
+	fn(body)
+	ms.values[id] = body
+
+	gi := &model.GrantInfo{}
+	if err := gi.Unmarshal(body); err != nil {
+		return nil, err
+	}
+
+	return gi, nil
+}
+
+// find returns the grants accepted by the given filter.
+func (ms *MemoryStore) find(filter func(gi *model.GrantInfo) bool) ([]*model.GrantInfo, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var grants []*model.GrantInfo
+	for _, body := range ms.values {
+		gi := &model.GrantInfo{}
+		if err := gi.Unmarshal(body); err != nil {
+			return nil, err
+		}
+
+		if filter(gi) {
+			grants = append(grants, gi)
+		}
+	}
+
+	return grants, nil
+}
@@ -0,0 +1,203 @@
+package grants
+
+import (
+	"context"
+	"errors"
+	"github.com/Mides-Projects/Kyro/grants/model"
+	"github.com/Mides-Projects/Kyro/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// MongoStore is a GrantStore backed by a MongoDB collection.
+type MongoStore struct {
+	col *mongo.Collection
+	ctx context.Context
+}
+
+func NewMongoStore(col *mongo.Collection) *MongoStore {
+	return &MongoStore{
+		col: col,
+		// caching the context helps a lot with performance and memory usage
+		ctx: context.Background(),
+	}
+}
+
+// indexes are the indexes the grants collection relies on: players are looked up by
+// source_id, groups by grant.key and grant.value, and expiring grants by expires_at.
+var indexes = []storage.Index{
+	{Name: "source_id", Keys: []string{"source_id"}},
+	{Name: "grant", Keys: []string{"grant.key", "grant.value"}},
+	{Name: "expires_at", Keys: []string{"expires_at"}},
+}
+
+// EnsureIndexes creates the indexes the store relies on if they are missing.
+func (ms *MongoStore) EnsureIndexes() error {
+	return storage.EnsureIndexes(ms.ctx, ms.col, indexes)
+}
+
+// FindByPlayer returns every grant of the player with the given ID, active or not.
+func (ms *MongoStore) FindByPlayer(id string) ([]*model.GrantInfo, error) {
+	return ms.find(bson.M{"source_id": id})
+}
+
+// FindByID returns the grant with the given ID or ErrGrantNotFound.
+func (ms *MongoStore) FindByID(id string) (*model.GrantInfo, error) {
+	var body map[string]interface{}
+	if err := ms.col.FindOne(ms.ctx, bson.M{"_id": id}).Decode(&body); errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrGrantNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	gi := &model.GrantInfo{}
+	if err := gi.Unmarshal(body); err != nil {
+		return nil, err
+	}
+
+	return gi, nil
+}
+
+// FindByGrant returns every grant with the given key and value that is not revoked.
+func (ms *MongoStore) FindByGrant(key, value string) ([]*model.GrantInfo, error) {
+	return ms.find(bson.M{
+		"grant.key":   key,
+		"grant.value": value,
+		"revoked_at":  bson.M{"$exists": false},
+	})
+}
+
+// FindPage returns a page of the grants matching the query, ordered by ID.
+func (ms *MongoStore) FindPage(q GrantQuery) ([]*model.GrantInfo, error) {
+	filter := bson.M{
+		"grant.key":   q.Key,
+		"grant.value": q.Value,
+	}
+
+	// Like model.GrantInfo.Expired, a grant expiring at or before the epoch never expires.
+	now := time.Now().Unix()
+	switch q.State {
+	case StateActive:
+		filter["revoked_at"] = bson.M{"$exists": false}
+		filter["$or"] = bson.A{
+			bson.M{"expires_at": bson.M{"$lte": 0}},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		}
+	case StateExpired:
+		filter["$or"] = bson.A{
+			bson.M{"revoked_at": bson.M{"$exists": true}},
+			bson.M{"expires_at": bson.M{"$gt": 0, "$lte": now}},
+		}
+	}
+
+	if q.After != "" {
+		filter["_id"] = bson.M{"$gt": q.After}
+	}
+
+	return ms.find(filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(q.Limit)))
+}
+
+// Insert inserts a new grant.
+func (ms *MongoStore) Insert(gi *model.GrantInfo) error {
+	_, err := ms.col.InsertOne(ms.ctx, gi.Marshal())
+
+	return err
+}
+
+// SetGrantValue sets the value of the grant with the given ID unless it is revoked.
+func (ms *MongoStore) SetGrantValue(id, value string) (*model.GrantInfo, error) {
+	return ms.updateActive(id, bson.M{"grant.value": value})
+}
+
+// SetExpiresAt sets when the grant with the given ID expires unless it is revoked.
+func (ms *MongoStore) SetExpiresAt(id string, expiresAt time.Time) (*model.GrantInfo, error) {
+	var at int64
+	if !expiresAt.IsZero() {
+		at = expiresAt.Unix()
+	}
+
+	return ms.updateActive(id, bson.M{"expires_at": at})
+}
+
+// Revoke marks the grant with the given ID as revoked.
+func (ms *MongoStore) Revoke(id, revokedBy string, revokedAt time.Time, reason string) error {
+	result, err := ms.col.UpdateOne(
+		ms.ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{
+			"revoked_by":    revokedBy,
+			"revoked_at":    revokedAt.Unix(),
+			"revoke_reason": reason,
+		}},
+	)
+	if err != nil {
+		return err
+	} else if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// Nothing matched, tell apart a missing grant from a revoked one.
+	if _, err = ms.FindByID(id); err != nil {
+		return err
+	}
+
+	return ErrGrantRevoked
+}
+
+// updateActive sets the given fields on the grant with the given ID unless it is revoked,
+// and returns the updated grant. Only the given fields are written so a concurrent
+// revocation is never overwritten.
+func (ms *MongoStore) updateActive(id string, set bson.M) (*model.GrantInfo, error) {
+	var body map[string]interface{}
+	err := ms.col.FindOneAndUpdate(
+		ms.ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&body)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Nothing matched, tell apart a missing grant from a revoked one.
+		if _, err = ms.FindByID(id); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrGrantRevoked
+	} else if err != nil {
+		return nil, err
+	}
+
+	gi := &model.GrantInfo{}
+	if err = gi.Unmarshal(body); err != nil {
+		return nil, err
+	}
+
+	return gi, nil
+}
+
+// find returns the grants matching the given filter.
+func (ms *MongoStore) find(filter bson.M, opts ...*options.FindOptions) ([]*model.GrantInfo, error) {
+	cur, err := ms.col.Find(ms.ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ms.ctx)
+
+	var grants []*model.GrantInfo
+	for cur.Next(ms.ctx) {
+		var body map[string]interface{}
+		if err = cur.Decode(&body); err != nil {
+			return nil, err
+		}
+
+		gi := &model.GrantInfo{}
+		if err = gi.Unmarshal(body); err != nil {
+			return nil, err
+		}
+
+		grants = append(grants, gi)
+	}
+
+	return grants, cur.Err()
+}
@@ -0,0 +1,245 @@
+package grants
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/Mides-Projects/Kyro/grants/model"
+	"github.com/bytedance/sonic"
+	"time"
+)
+
+// sqliteColumns are the columns selected by SQLiteStore, in the order scanned by scanGrant.
+const sqliteColumns = `id, source_id, grant_key, grant_value, added_by, added_at, expires_at,
+	revoked_by, revoked_at, revoke_reason, scopes`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// SQLiteStore is a GrantStore backed by the grants table of an SQLite database,
+// see storage.SQLite for the schema.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{
+		db: db,
+	}
+}
+
+// FindByPlayer returns every grant of the player with the given ID, active or not.
+func (ss *SQLiteStore) FindByPlayer(id string) ([]*model.GrantInfo, error) {
+	return ss.find(`SELECT `+sqliteColumns+` FROM grants WHERE source_id = ?`, id)
+}
+
+// FindByID returns the grant with the given ID or ErrGrantNotFound.
+func (ss *SQLiteStore) FindByID(id string) (*model.GrantInfo, error) {
+	gi, err := scanGrant(ss.db.QueryRow(`SELECT `+sqliteColumns+` FROM grants WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGrantNotFound
+	}
+
+	return gi, err
+}
+
+// FindByGrant returns every grant with the given key and value that is not revoked.
+func (ss *SQLiteStore) FindByGrant(key, value string) ([]*model.GrantInfo, error) {
+	return ss.find(
+		`SELECT `+sqliteColumns+` FROM grants WHERE grant_key = ? AND grant_value = ? AND revoked_at IS NULL`,
+		key,
+		value,
+	)
+}
+
+// FindPage returns a page of the grants matching the query, ordered by ID.
+func (ss *SQLiteStore) FindPage(q GrantQuery) ([]*model.GrantInfo, error) {
+	query := `SELECT ` + sqliteColumns + ` FROM grants WHERE grant_key = ? AND grant_value = ? AND id > ?`
+	args := []interface{}{q.Key, q.Value, q.After}
+
+	// Like model.GrantInfo.Expired, a grant expiring at or before the epoch never expires.
+	switch q.State {
+	case StateActive:
+		query += ` AND revoked_at IS NULL AND (expires_at <= 0 OR expires_at > ?)`
+		args = append(args, time.Now().Unix())
+	case StateExpired:
+		query += ` AND (revoked_at IS NOT NULL OR (expires_at > 0 AND expires_at <= ?))`
+		args = append(args, time.Now().Unix())
+	}
+
+	return ss.find(query+` ORDER BY id LIMIT ?`, append(args, q.Limit)...)
+}
+
+// Insert inserts a new grant.
+func (ss *SQLiteStore) Insert(gi *model.GrantInfo) error {
+	args, err := sqliteArgs(gi)
+	if err != nil {
+		return err
+	}
+
+	_, err = ss.db.Exec(
+		`INSERT INTO grants (`+sqliteColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		args...,
+	)
+
+	return err
+}
+
+// SetGrantValue sets the value of the grant with the given ID unless it is revoked.
+func (ss *SQLiteStore) SetGrantValue(id, value string) (*model.GrantInfo, error) {
+	return ss.updateActive(id, `grant_value = ?`, value)
+}
+
+// SetExpiresAt sets when the grant with the given ID expires unless it is revoked.
+func (ss *SQLiteStore) SetExpiresAt(id string, expiresAt time.Time) (*model.GrantInfo, error) {
+	var at int64
+	if !expiresAt.IsZero() {
+		at = expiresAt.Unix()
+	}
+
+	return ss.updateActive(id, `expires_at = ?`, at)
+}
+
+// Revoke marks the grant with the given ID as revoked.
+func (ss *SQLiteStore) Revoke(id, revokedBy string, revokedAt time.Time, reason string) error {
+	result, err := ss.db.Exec(
+		`UPDATE grants SET revoked_by = ?, revoked_at = ?, revoke_reason = ? WHERE id = ? AND revoked_at IS NULL`,
+		revokedBy,
+		revokedAt.Unix(),
+		reason,
+		id,
+	)
+	if err != nil {
+		return err
+	} else if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		return nil
+	}
+
+	// Nothing matched, tell apart a missing grant from a revoked one.
+	if _, err = ss.FindByID(id); err != nil {
+		return err
+	}
+
+	return ErrGrantRevoked
+}
+
+// updateActive applies the SET clause with its arguments to the grant with the given ID
+// unless it is revoked, and returns the updated grant. Only the given columns are written
+// so a concurrent revocation is never overwritten.
+func (ss *SQLiteStore) updateActive(id, set string, args ...interface{}) (*model.GrantInfo, error) {
+	gi, err := scanGrant(ss.db.QueryRow(
+		`UPDATE grants SET `+set+` WHERE id = ? AND revoked_at IS NULL RETURNING `+sqliteColumns,
+		append(args, id)...,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nothing matched, tell apart a missing grant from a revoked one.
+		if _, err = ss.FindByID(id); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrGrantRevoked
+	}
+
+	return gi, err
+}
+
+// find returns the grants selected by the given query.
+func (ss *SQLiteStore) find(query string, args ...interface{}) ([]*model.GrantInfo, error) {
+	rows, err := ss.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var grants []*model.GrantInfo
+	for rows.Next() {
+		gi, err := scanGrant(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		grants = append(grants, gi)
+	}
+
+	return grants, rows.Err()
+}
+
+// sqliteArgs returns the values of the grant in the order of sqliteColumns.
+// The grant is marshaled first so the row holds the same values as a MongoDB document.
+func sqliteArgs(gi *model.GrantInfo) ([]interface{}, error) {
+	body := gi.Marshal()
+
+	scopes, err := sonic.MarshalString(body["scopes"])
+	if err != nil {
+		return nil, err
+	}
+
+	var revokedBy, revokedAt, revokeReason interface{}
+	if _, ok := body["revoked_at"]; ok {
+		revokedBy, revokedAt, revokeReason = body["revoked_by"], body["revoked_at"], body["revoke_reason"]
+	}
+
+	grant := gi.Grant()
+
+	return []interface{}{
+		gi.ID(),
+		gi.SourceID(),
+		grant.Key(),
+		grant.Value(),
+		body["added_by"],
+		body["added_at"],
+		body["expires_at"],
+		revokedBy,
+		revokedAt,
+		revokeReason,
+		scopes,
+	}, nil
+}
+
+// scanGrant reads a grant selected with sqliteColumns.
+// The row is turned into the body of a MongoDB document so it goes through the same unmarshalling.
+func scanGrant(row rowScanner) (*model.GrantInfo, error) {
+	var (
+		id, sourceID, key, value, addedBy, scopes string
+		addedAt, expiresAt                        int64
+		revokedBy, revokeReason                   sql.NullString
+		revokedAt                                 sql.NullInt64
+	)
+	if err := row.Scan(&id, &sourceID, &key, &value, &addedBy, &addedAt, &expiresAt, &revokedBy, &revokedAt, &revokeReason, &scopes); err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"_id":       id,
+		"source_id": sourceID,
+		"grant": map[string]interface{}{
+			"key":   key,
+			"value": value,
+		},
+		"added_by":   addedBy,
+		"added_at":   addedAt,
+		"expires_at": expiresAt,
+	}
+
+	if revokedAt.Valid {
+		body["revoked_by"] = revokedBy.String
+		body["revoked_at"] = revokedAt.Int64
+		body["revoke_reason"] = revokeReason.String
+	}
+
+	var scopeValues []interface{}
+	if err := sonic.UnmarshalString(scopes, &scopeValues); err != nil {
+		return nil, err
+	}
+	body["scopes"] = scopeValues
+
+	gi := &model.GrantInfo{}
+	if err := gi.Unmarshal(body); err != nil {
+		return nil, err
+	}
+
+	return gi, nil
+}
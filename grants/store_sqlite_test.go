@@ -0,0 +1,64 @@
+package grants
+
+import (
+	"github.com/Mides-Projects/Kyro/grants/model"
+	"github.com/Mides-Projects/Kyro/storage"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSQLiteFindPageMatchesExpired(t *testing.T) {
+	db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "kyro.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stores := map[string]GrantStore{
+		"memory": NewMemoryStore(),
+		"sqlite": NewSQLiteStore(db),
+	}
+
+	now := time.Now()
+	grants := []*model.GrantInfo{
+		model.NewGrantInfo("g1", "p1", model.NewGrant(model.KeyGroup, "admin"), "console", now, time.Time{}, nil),
+		model.NewGrantInfo("g2", "p2", model.NewGrant(model.KeyGroup, "admin"), "console", now, time.Unix(-60, 0), nil),
+		model.NewGrantInfo("g3", "p3", model.NewGrant(model.KeyGroup, "admin"), "console", now, now.Add(-time.Hour), nil),
+		model.NewGrantInfo("g4", "p4", model.NewGrant(model.KeyGroup, "admin"), "console", now, now.Add(time.Hour), nil),
+		model.NewGrantInfo("g5", "p5", model.NewGrant(model.KeyGroup, "admin"), "console", now, time.Time{}, nil),
+	}
+	for _, store := range stores {
+		for _, gi := range grants {
+			if err = store.Insert(gi); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = store.Revoke("g5", "moderator", now, "abuse"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string][]string{
+		StateActive:  {"g1", "g2", "g4"},
+		StateExpired: {"g3", "g5"},
+		StateAll:     {"g1", "g2", "g3", "g4", "g5"},
+	}
+	for name, store := range stores {
+		for state, ids := range want {
+			page, err := store.FindPage(GrantQuery{Key: model.KeyGroup, Value: "admin", State: state, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0, len(page))
+			for _, gi := range page {
+				got = append(got, gi.ID())
+			}
+			if !slices.Equal(got, ids) {
+				t.Errorf("%s %s grants = %v, want %v", name, state, got, ids)
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Mides-Projects/Operator/helper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"slices"
+	"strings"
+)
+
+// Index is an index of a MongoDB collection a service relies on.
+type Index struct {
+	Name            string   // Name is the name given to the index when it is created.
+	Keys            []string // Keys are the indexed fields, in order and ascending.
+	Unique          bool     // Unique is if two documents cannot have the same keys.
+	CaseInsensitive bool     // CaseInsensitive is if strings are compared ignoring case.
+}
+
+// indexSpec is the part of an existing index that is compared with the declared ones.
+type indexSpec struct {
+	Name      string `bson:"name"`
+	Key       bson.D `bson:"key"`
+	Unique    bool   `bson:"unique"`
+	Collation *struct {
+		Strength int `bson:"strength"`
+	} `bson:"collation"`
+}
+
+// ErrIndexDrift is returned when an existing index does not enforce the uniqueness
+// or the case insensitivity it is declared with.
+var ErrIndexDrift = errors.New("index differs from its declaration")
+
+// EnsureIndexes creates the declared indexes that are missing in the collection.
+// An index is found by its keys, so an existing one is kept even under another name.
+// Existing indexes whose options differ from the declared ones, and indexes that are
+// not declared at all, are left untouched so the drift can be fixed by hand.
+// The drift is only logged, unless a declared unique or case-insensitive index is not
+// enforced as such, in which case ErrIndexDrift is returned since the services rely on it.
+func EnsureIndexes(ctx context.Context, col *mongo.Collection, indexes []Index) error {
+	cur, err := col.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var existing []indexSpec
+	if err = cur.All(ctx, &existing); err != nil {
+		return err
+	}
+
+	var (
+		missing []mongo.IndexModel
+		drifts  []error
+	)
+	for _, index := range indexes {
+		i := slices.IndexFunc(existing, func(spec indexSpec) bool {
+			return spec.keysEqual(index.Keys)
+		})
+		if i < 0 {
+			missing = append(missing, index.model())
+
+			continue
+		}
+
+		spec := existing[i]
+		existing = slices.Delete(existing, i, i+1)
+
+		caseInsensitive := spec.Collation != nil && spec.Collation.Strength <= 2
+		if spec.Unique == index.Unique && caseInsensitive == index.CaseInsensitive {
+			continue
+		} else if index.Unique || index.CaseInsensitive {
+			drifts = append(drifts, fmt.Errorf(
+				"%w: index '%s' of '%s' must be unique=%t case_insensitive=%t",
+				ErrIndexDrift,
+				spec.Name,
+				col.Name(),
+				index.Unique,
+				index.CaseInsensitive,
+			))
+
+			continue
+		}
+
+		helper.Log.Error(
+			helper.ServiceId+": index differs from its declaration",
+			"collection", col.Name(),
+			"index", spec.Name,
+			"unique", index.Unique,
+			"case_insensitive", index.CaseInsensitive,
+		)
+	}
+
+	if len(drifts) > 0 {
+		return errors.Join(drifts...)
+	}
+
+	for _, spec := range existing {
+		if spec.Name != "_id_" {
+			helper.Log.Error(helper.ServiceId+": index is not declared", "collection", col.Name(), "index", spec.Name)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	names, err := col.Indexes().CreateMany(ctx, missing)
+	if err != nil {
+		return err
+	}
+
+	helper.Log.Info(helper.ServiceId+": successfully created missing indexes", "collection", col.Name(), "indexes", strings.Join(names, ", "))
+
+	return nil
+}
+
+// keysEqual returns if the index has exactly the given keys, in order.
+func (spec indexSpec) keysEqual(keys []string) bool {
+	return slices.EqualFunc(spec.Key, keys, func(e bson.E, key string) bool {
+		return e.Key == key
+	})
+}
+
+// model returns the model creating the index.
+func (index Index) model() mongo.IndexModel {
+	keys := make(bson.D, 0, len(index.Keys))
+	for _, key := range index.Keys {
+		keys = append(keys, bson.E{Key: key, Value: 1})
+	}
+
+	opts := options.Index().SetName(index.Name)
+	if index.Unique {
+		opts.SetUnique(true)
+	}
+	if index.CaseInsensitive {
+		opts.SetCollation(&options.Collation{Locale: "en", Strength: 2})
+	}
+
+	return mongo.IndexModel{
+		Keys:    keys,
+		Options: opts,
+	}
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// migrations are the schema changes of the SQLite database, in order.
+// The index of the last applied migration is kept in the user_version pragma,
+// so a migration must never be changed once released, only appended.
+var migrations = []string{
+	`CREATE TABLE grants (
+		id            TEXT PRIMARY KEY,
+		source_id     TEXT NOT NULL,
+		grant_key     TEXT NOT NULL,
+		grant_value   TEXT NOT NULL,
+		added_by      TEXT NOT NULL,
+		added_at      INTEGER NOT NULL,
+		expires_at    INTEGER NOT NULL DEFAULT 0,
+		revoked_by    TEXT,
+		revoked_at    INTEGER,
+		revoke_reason TEXT,
+		scopes        TEXT NOT NULL DEFAULT '[]'
+	);
+	CREATE INDEX grants_source_id ON grants (source_id);
+	CREATE INDEX grants_grant ON grants (grant_key, grant_value);
+
+	CREATE TABLE groups (
+		id   TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		body TEXT NOT NULL
+	);`,
+	`CREATE UNIQUE INDEX groups_name ON groups (name COLLATE NOCASE);`,
+	`CREATE INDEX grants_expires_at ON grants (expires_at);`,
+	`CREATE TABLE audit (
+		id          TEXT PRIMARY KEY,
+		action      TEXT NOT NULL,
+		actor       TEXT NOT NULL,
+		player_id   TEXT NOT NULL,
+		group_id    TEXT NOT NULL,
+		at          INTEGER NOT NULL,
+		before_body TEXT,
+		after_body  TEXT
+	);
+	CREATE INDEX audit_at ON audit (at, id);
+	CREATE INDEX audit_actor_at ON audit (actor, at);
+	CREATE INDEX audit_player_id_at ON audit (player_id, at);
+	CREATE INDEX audit_group_id_at ON audit (group_id, at);`,
+}
+
+var (
+	sqliteDB   *sql.DB
+	sqliteErr  error
+	sqliteOnce sync.Once
+)
+
+// SQLite returns the SQLite database shared by the services, opened at the path of the
+// KYRO_SQLITE_PATH environment variable (kyro.db by default) and migrated to the latest schema.
+func SQLite() (*sql.DB, error) {
+	sqliteOnce.Do(func() {
+		path := os.Getenv("KYRO_SQLITE_PATH")
+		if path == "" {
+			path = "kyro.db"
+		}
+
+		sqliteDB, sqliteErr = OpenSQLite(path)
+	})
+
+	return sqliteDB, sqliteErr
+}
+
+// OpenSQLite opens the SQLite database at the given path and migrates it to the latest schema.
+// Transactions take the write lock when they begin, as a read-then-write transaction
+// upgrading its lock fails with SQLITE_BUSY right away instead of waiting for the busy timeout.
+func OpenSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = migrate(db); err != nil {
+		return nil, errors.Join(db.Close(), err)
+	}
+
+	return db, nil
+}
+
+// migrate applies the migrations that are missing in the database.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for ; version < len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(migrations[version]); err != nil {
+			return errors.Join(errors.New("migration "+strconv.Itoa(version+1)+" failed"), err, tx.Rollback())
+		} else if _, err = tx.Exec("PRAGMA user_version = " + strconv.Itoa(version+1)); err != nil {
+			return errors.Join(err, tx.Rollback())
+		} else if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsUniqueViolation returns if the error is caused by a row breaking a UNIQUE index.
+func IsUniqueViolation(err error) bool {
+	var se *sqlite.Error
+
+	return errors.As(err, &se) && se.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -0,0 +1,19 @@
+package storage
+
+import "os"
+
+const (
+	// DriverMongo stores the grants and groups in MongoDB, the default.
+	DriverMongo = "mongo"
+	// DriverSQLite stores the grants and groups in a local SQLite database.
+	DriverSQLite = "sqlite"
+)
+
+// Driver returns the storage driver selected with the KYRO_STORAGE environment variable.
+func Driver() string {
+	if driver := os.Getenv("KYRO_STORAGE"); driver != "" {
+		return driver
+	}
+
+	return DriverMongo
+}